@@ -0,0 +1,95 @@
+package async
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"strings"
+)
+
+// ErrPanicked 函数panic。设置WithPanicError时，得到的错误是*PanicError，可以用errors.Is判断。
+var ErrPanicked = errors.New("task panicked")
+
+// PanicError 设置WithPanicError时，函数panic转成的错误。
+type PanicError struct {
+	// Value recover()的结果。
+	Value interface{}
+
+	// Index 函数的下标。Graceful.Run时为0。
+	Index int
+
+	// Stack panic时函数所在协程的调用栈。
+	Stack []byte
+}
+
+func newPanicError(value interface{}, index int) *PanicError {
+	return &PanicError{
+		Value: value,
+		Index: index,
+		Stack: debug.Stack(),
+	}
+}
+
+// Error 实现error接口。
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("task %d panicked: %v", e.Index, e.Value)
+}
+
+// Unwrap 支持errors.Is(err, ErrPanicked)。
+func (e *PanicError) Unwrap() error {
+	return ErrPanicked
+}
+
+// TaskError 一个函数的错误。
+type TaskError struct {
+	// Index 函数的下标。
+	Index int
+
+	// Err 函数返回的错误。
+	Err error
+}
+
+// Error 实现error接口。
+func (e *TaskError) Error() string {
+	return fmt.Sprintf("task %d: %v", e.Index, e.Err)
+}
+
+// Unwrap 返回函数的错误。
+func (e *TaskError) Unwrap() error {
+	return e.Err
+}
+
+// MultiError 多个函数的错误。按函数的下标排序。
+// 支持errors.Is和errors.As，匹配其中任一错误。
+type MultiError struct {
+	Errors []*TaskError
+}
+
+// Error 实现error接口。
+func (e *MultiError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Is 是否有错误匹配target。
+func (e *MultiError) Is(target error) bool {
+	for _, err := range e.Errors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As 找到第一个匹配target的错误，赋值给target。
+func (e *MultiError) As(target interface{}) bool {
+	for _, err := range e.Errors {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
@@ -8,22 +8,34 @@ import (
 	"github.com/wencan/freesync"
 )
 
-// DefaultGraceful 默认的Graceful
+// DefaultGraceful 默认的Graceful。不设置任何选项；需要选项时，使用NewGraceful新建。
 var DefaultGraceful = Graceful{name: "default"}
 
 // Graceful 运行并graceful退出。
 type Graceful struct {
 	name string
 
+	opts options
+
 	branches freesync.Slice
 
 	counter atomic.Uint64
 }
 
+// NewGraceful 新建一个根Graceful对象。
+func NewGraceful(name string, opts ...Option) *Graceful {
+	return &Graceful{
+		name: name,
+		opts: newOptions(opts...),
+	}
+}
+
 // NewBranch 新建一个分支。
-func (graceful *Graceful) NewBranch(name string) *Graceful {
+// 分支不继承当前Graceful对象的选项，只使用opts。
+func (graceful *Graceful) NewBranch(name string, opts ...Option) *Graceful {
 	g := &Graceful{
 		name: name,
+		opts: newOptions(opts...),
 	}
 	graceful.branches.Append(g)
 
@@ -47,11 +59,33 @@ func (graceful *Graceful) BusyBranches() []string {
 }
 
 // Run 新运行一个函数。
+// 设置WithPanicError时，f的panic转为*PanicError再次panic。
 func (graceful *Graceful) Run(f func()) {
+	err := graceful.RunE(func() error {
+		f()
+		return nil
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// RunE 新运行一个函数，返回它的错误。
+// 设置WithPanicError时，f的panic转为*PanicError返回。
+func (graceful *Graceful) RunE(f func() error) error {
 	graceful.counter.Add(1)
 	defer graceful.counter.Add(^uint64(0))
 
-	f()
+	if !graceful.opts.panicError {
+		return f()
+	}
+	panicErr, err := call(context.Background(), 0, func(ctx context.Context) error {
+		return f()
+	})
+	if panicErr != nil {
+		return panicErr
+	}
+	return err
 }
 
 // Wait 等待所有当前Graceful对象运行的函数退出，或者ctx错误。
@@ -0,0 +1,25 @@
+package async
+
+// options 选项。
+type options struct {
+	panicError bool
+}
+
+// Option 选项。
+type Option func(*options)
+
+// WithPanicError 将函数的panic转为*PanicError，作为函数的错误处理，而不是再次panic。
+// *PanicError包含recover()的结果、函数的下标，以及panic时函数所在协程的调用栈。
+func WithPanicError() Option {
+	return func(o *options) {
+		o.panicError = true
+	}
+}
+
+func newOptions(opts ...Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
@@ -1,6 +1,8 @@
 package async
 
 import (
+	"context"
+	"errors"
 	"sync"
 	"sync/atomic"
 )
@@ -13,46 +15,97 @@ func Parallel(funcs ...func() error) error {
 }
 
 // ParallelLimit 并行执行一组函数，等待执行完成。
-// 每次调用最多只执行limit路并发执行。前面的函数优先执行。
+// 每次调用最多只执行limit路并发执行，limit<=0时不执行任何函数。前面的函数优先执行。
 // 如果其中一个或多个函数返回错误，未执行的函数不再执行，并优先选择前面函数的错误返回。
 // 如果其中一个或多个函数panic，未执行的函数不再执行，并优先选择前面函数的recover()的非nil结果再次panic。
 func ParallelLimit(limit int, funcs ...func() error) error {
-	var errs = make([]error, len(funcs))
-	var recovereds = make([]interface{}, len(funcs))
+	return defaultRunner.ParallelLimit(limit, funcs...)
+}
+
+// ParallelCtx 同Parallel，但每个函数接收派生的ctx。
+// 任一函数返回错误或者panic时，派生的ctx被取消，以通知执行中的其它函数。
+// 如果ctx结束，未执行的函数不再执行，等待执行中的函数退出后，返回ctx的错误。
+func ParallelCtx(ctx context.Context, funcs ...func(ctx context.Context) error) error {
+	return ParallelLimitCtx(ctx, len(funcs), funcs...)
+}
+
+// ParallelLimitCtx 同ParallelLimit，但每个函数接收派生的ctx。limit<=0时不限制。
+// 任一函数返回错误或者panic时，派生的ctx被取消，以通知执行中的其它函数。
+// 其它函数因为派生的ctx被取消而返回的context.Canceled，不作为结果，返回引起取消的错误。
+// 如果ctx结束，未执行的函数不再执行，等待执行中的函数退出后，返回ctx的错误。
+func ParallelLimitCtx(ctx context.Context, limit int, funcs ...func(ctx context.Context) error) error {
+	return defaultRunner.ParallelLimitCtx(ctx, limit, funcs...)
+}
+
+// ParallelAll 并行执行一组函数，等待全部执行完成。不因为错误停止执行。
+// 每次调用最多只执行limit路并发执行，limit<=0时不限制。前面的函数优先执行。
+// 如果其中一个或多个函数返回错误，返回*MultiError，包含每个错误和对应函数的下标。
+// 如果其中一个或多个函数panic，同ParallelLimit。
+func ParallelAll(limit int, funcs ...func() error) error {
+	return ParallelAllCtx(context.Background(), limit, withoutContext(funcs)...)
+}
+
+// ParallelAllCtx 同ParallelAll，但每个函数接收派生的ctx。派生的ctx只在panic时取消。
+// 如果ctx结束，未执行的函数不再执行，它们的错误为ctx的错误。
+func ParallelAllCtx(ctx context.Context, limit int, funcs ...func(ctx context.Context) error) error {
+	return defaultRunner.ParallelAllCtx(ctx, limit, funcs...)
+}
+
+// parallel 并行执行一组函数，返回每个函数的错误，以及是否执行。
+// collectAll为false时，出错后取消派生的ctx，未执行的函数不再执行。
+// 如果其中一个或多个函数panic，未执行的函数不再执行，并优先选择前面函数的recover()的非nil结果再次panic。
+// 设置WithPanicError时，panic转为*PanicError，同错误处理。
+func parallel(ctx context.Context, limit int, collectAll bool, opts options, funcs []func(ctx context.Context) error) (errs []error, executed []bool) {
+	errs = make([]error, len(funcs))
+	executed = make([]bool, len(funcs))
+	var panicErrs = make([]*PanicError, len(funcs))
 	var wg sync.WaitGroup
 	var interrupted uint32
 
+	childCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
 	var counter uint32
 	for i := 0; i < limit; i++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
 
-			var index int // 第几个函数
-
-			defer func() {
-				r := recover()
-				if r != nil {
-					atomic.SwapUint32(&interrupted, 1)
-					recovereds[index] = r
-				}
-			}()
-
 			for {
 				if atomic.LoadUint32(&interrupted) != 0 {
 					// 前面的函数错误或者panic
 					// 不再执行后面的函数
 					break
 				}
+				if ctx.Err() != nil {
+					// 上级ctx结束
+					break
+				}
 
-				index = int(atomic.AddUint32(&counter, 1) - 1)
+				index := int(atomic.AddUint32(&counter, 1) - 1)
 				if index >= len(funcs) {
 					break
 				}
 
-				err := funcs[index]()
+				executed[index] = true
+				panicErr, err := call(childCtx, index, funcs[index])
+				if panicErr != nil {
+					if !opts.panicError {
+						atomic.SwapUint32(&interrupted, 1)
+						cancel()
+						panicErrs[index] = panicErr
+						break
+					}
+					err = panicErr
+				}
 				if err != nil {
-					atomic.SwapUint32(&interrupted, 1)
+					if !collectAll {
+						if atomic.SwapUint32(&interrupted, 1) != 0 && ctx.Err() == nil && errors.Is(err, context.Canceled) {
+							// 其它函数出错后，派生的ctx被取消引起的错误
+							continue
+						}
+						cancel()
+					}
 					errs[index] = err
 				}
 			}
@@ -61,16 +114,35 @@ func ParallelLimit(limit int, funcs ...func() error) error {
 
 	wg.Wait()
 
-	for _, r := range recovereds {
-		if r != nil {
-			panic(r)
+	for _, panicErr := range panicErrs {
+		if panicErr != nil {
+			panic(panicErr.Value)
 		}
 	}
-	for _, err := range errs {
-		if err != nil {
-			return err
+
+	return errs, executed
+}
+
+// call 执行f，recover它的panic。
+func call(ctx context.Context, index int, f func(ctx context.Context) error) (panicErr *PanicError, err error) {
+	defer func() {
+		r := recover()
+		if r != nil {
+			panicErr = newPanicError(r, index)
 		}
-	}
+	}()
 
-	return nil
+	return nil, f(ctx)
+}
+
+// withoutContext 将不接收ctx的函数转为接收ctx的函数。
+func withoutContext(funcs []func() error) []func(ctx context.Context) error {
+	ctxFuncs := make([]func(ctx context.Context) error, len(funcs))
+	for i, f := range funcs {
+		f := f
+		ctxFuncs[i] = func(ctx context.Context) error {
+			return f()
+		}
+	}
+	return ctxFuncs
 }
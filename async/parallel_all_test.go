@@ -0,0 +1,68 @@
+package async
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParallelAll(t *testing.T) {
+	// 全部成功
+	err := ParallelAll(2, func() error {
+		return nil
+	}, func() error {
+		return nil
+	})
+	assert.Nil(t, err)
+
+	// 出错后继续执行，返回全部错误
+	var counter int32
+	err = ParallelAll(1, func() error {
+		atomic.AddInt32(&counter, 1)
+		return io.EOF
+	}, func() error {
+		atomic.AddInt32(&counter, 1)
+		return nil
+	}, func() error {
+		atomic.AddInt32(&counter, 1)
+		return &os.PathError{Op: "open", Path: "test", Err: os.ErrNotExist}
+	})
+	assert.Equal(t, int32(3), counter)
+
+	var multiErr *MultiError
+	if assert.True(t, errors.As(err, &multiErr)) && assert.Len(t, multiErr.Errors, 2) {
+		assert.Equal(t, 0, multiErr.Errors[0].Index)
+		assert.Equal(t, io.EOF, multiErr.Errors[0].Err)
+		assert.Equal(t, 2, multiErr.Errors[1].Index)
+	}
+	assert.True(t, errors.Is(err, io.EOF))
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+	assert.False(t, errors.Is(err, io.ErrUnexpectedEOF))
+	var pathErr *os.PathError
+	if assert.True(t, errors.As(err, &pathErr)) {
+		assert.Equal(t, "test", pathErr.Path)
+	}
+	assert.Equal(t, "task 0: EOF; task 2: open test: file does not exist", err.Error())
+}
+
+func TestParallelAllCtx(t *testing.T) {
+	// 上级ctx结束后，未执行的函数得到ctx的错误
+	ctx, cancel := context.WithCancel(context.TODO())
+	err := ParallelAllCtx(ctx, 1, func(ctx context.Context) error {
+		cancel()
+		return nil
+	}, func(ctx context.Context) error {
+		t.Error("should not run")
+		return nil
+	})
+	var multiErr *MultiError
+	if assert.True(t, errors.As(err, &multiErr)) && assert.Len(t, multiErr.Errors, 1) {
+		assert.Equal(t, 1, multiErr.Errors[0].Index)
+	}
+	assert.True(t, errors.Is(err, context.Canceled))
+}
@@ -0,0 +1,129 @@
+package async
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParallelCtx(t *testing.T) {
+	// 全部成功
+	var counter int32
+	err := ParallelCtx(context.TODO(), func(ctx context.Context) error {
+		atomic.AddInt32(&counter, 1)
+		return nil
+	}, func(ctx context.Context) error {
+		atomic.AddInt32(&counter, 1)
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, int32(2), counter)
+
+	// 出错后，执行中的函数被取消
+	wantErr := errors.New("wow")
+	start := time.Now()
+	err = ParallelCtx(context.TODO(), func(ctx context.Context) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second * 10):
+			return nil
+		}
+	}, func(ctx context.Context) error {
+		time.Sleep(time.Millisecond * 10)
+		return wantErr
+	})
+	// 返回引起取消的错误，而不是前面函数的context.Canceled
+	assert.Equal(t, wantErr, err)
+	assert.Less(t, time.Since(start), time.Second*5)
+
+	// panic后，执行中的函数被取消
+	func() {
+		defer func() {
+			assert.Equal(t, "wow", recover())
+		}()
+		_ = ParallelCtx(context.TODO(), func(ctx context.Context) error {
+			panic("wow")
+		}, func(ctx context.Context) error {
+			<-ctx.Done()
+			return nil
+		})
+	}()
+}
+
+func TestParallelLimitCtx(t *testing.T) {
+	// 上级ctx结束后，不再执行后面的函数
+	ctx, cancel := context.WithCancel(context.TODO())
+	var counter int32
+	err := ParallelLimitCtx(ctx, 1, func(ctx context.Context) error {
+		atomic.AddInt32(&counter, 1)
+		cancel()
+		return nil
+	}, func(ctx context.Context) error {
+		atomic.AddInt32(&counter, 1)
+		return nil
+	})
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, int32(1), counter)
+
+	// 已经结束的ctx
+	err = ParallelLimitCtx(ctx, 2, func(ctx context.Context) error {
+		t.Error("should not run")
+		return nil
+	})
+	assert.Equal(t, context.Canceled, err)
+
+	// ParallelLimit的limit<=0时，保持原有的行为，不执行任何函数
+	err = ParallelLimit(0, func() error {
+		t.Error("should not run")
+		return nil
+	})
+	assert.Nil(t, err)
+
+	// limit<=0时不限制
+	counter = 0
+	err = ParallelLimitCtx(context.TODO(), 0, func(ctx context.Context) error {
+		atomic.AddInt32(&counter, 1)
+		return nil
+	}, func(ctx context.Context) error {
+		atomic.AddInt32(&counter, 1)
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, int32(2), counter)
+}
+
+func TestSeriesCtx(t *testing.T) {
+	var steps []int
+	wantErr := errors.New("wow")
+	err := SeriesCtx(context.TODO(), func(ctx context.Context) error {
+		steps = append(steps, 1)
+		return nil
+	}, func(ctx context.Context) error {
+		steps = append(steps, 2)
+		return wantErr
+	}, func(ctx context.Context) error {
+		steps = append(steps, 3)
+		return nil
+	})
+	assert.Equal(t, wantErr, err)
+	assert.Equal(t, []int{1, 2}, steps)
+
+	// 上级ctx结束后，不再执行后面的函数
+	ctx, cancel := context.WithCancel(context.TODO())
+	steps = nil
+	err = SeriesCtx(ctx, func(ctx context.Context) error {
+		steps = append(steps, 1)
+		cancel()
+		return nil
+	}, func(ctx context.Context) error {
+		steps = append(steps, 2)
+		return nil
+	})
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, []int{1}, steps)
+}
@@ -0,0 +1,62 @@
+package async
+
+import "context"
+
+// ParallelMap 并行对inputs的每个元素执行f，返回的结果顺序同inputs。
+// 每次调用最多只执行limit路并发执行，limit<=0时不限制。前面的元素优先执行。
+// 如果其中一个或多个执行返回错误，未执行的元素不再执行，并优先选择前面元素的错误返回。
+// f接收的ctx同ParallelLimitCtx，出错或者panic时被取消。
+// 如果ctx结束，未执行的元素不再执行，返回ctx的错误。
+// 如果其中一个或多个执行panic，同ParallelLimit。
+func ParallelMap[T, R any](ctx context.Context, limit int, inputs []T, f func(ctx context.Context, input T) (R, error)) ([]R, error) {
+	results := make([]R, len(inputs))
+	funcs := make([]func(ctx context.Context) error, len(inputs))
+	for i := range inputs {
+		i := i
+		funcs[i] = func(ctx context.Context) error {
+			result, err := f(ctx, inputs[i])
+			if err != nil {
+				return err
+			}
+			results[i] = result
+			return nil
+		}
+	}
+
+	err := ParallelLimitCtx(ctx, parallelLimit(limit, len(inputs)), funcs...)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// ParallelMapErrors 同ParallelMap，但不因为错误停止执行，f接收的ctx不因为错误取消。
+// 返回的结果和错误的顺序同inputs，每个元素的错误对应各自的执行。
+// ctx结束后，未执行的元素得到ctx的错误。
+func ParallelMapErrors[T, R any](ctx context.Context, limit int, inputs []T, f func(ctx context.Context, input T) (R, error)) ([]R, []error) {
+	results := make([]R, len(inputs))
+	errs := make([]error, len(inputs))
+	funcs := make([]func() error, len(inputs))
+	for i := range inputs {
+		i := i
+		funcs[i] = func() error {
+			if err := ctx.Err(); err != nil {
+				errs[i] = err
+				return nil
+			}
+			results[i], errs[i] = f(ctx, inputs[i])
+			return nil
+		}
+	}
+
+	_ = ParallelLimit(parallelLimit(limit, len(inputs)), funcs...)
+	return results, errs
+}
+
+// parallelLimit 并发数。limit<=0时不限制。
+func parallelLimit(limit, n int) int {
+	if limit <= 0 || limit > n {
+		return n
+	}
+	return limit
+}
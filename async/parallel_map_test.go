@@ -0,0 +1,76 @@
+package async
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParallelMap(t *testing.T) {
+	inputs := []int{1, 2, 3, 4, 5}
+
+	// 结果顺序同inputs
+	results, err := ParallelMap(context.TODO(), 2, inputs, func(ctx context.Context, input int) (string, error) {
+		time.Sleep(time.Millisecond * time.Duration(10-input))
+		return strconv.Itoa(input), nil
+	})
+	if assert.Nil(t, err) {
+		assert.Equal(t, []string{"1", "2", "3", "4", "5"}, results)
+	}
+
+	// 不限制并发数
+	results, err = ParallelMap(context.TODO(), 0, inputs, func(ctx context.Context, input int) (string, error) {
+		return strconv.Itoa(input), nil
+	})
+	if assert.Nil(t, err) {
+		assert.Equal(t, []string{"1", "2", "3", "4", "5"}, results)
+	}
+
+	// 出错后，不再执行
+	var counter int32
+	wantErr := errors.New("wow")
+	results, err = ParallelMap(context.TODO(), 1, inputs, func(ctx context.Context, input int) (string, error) {
+		atomic.AddInt32(&counter, 1)
+		if input == 2 {
+			return "", wantErr
+		}
+		return strconv.Itoa(input), nil
+	})
+	assert.Equal(t, wantErr, err)
+	assert.Nil(t, results)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&counter))
+
+	// ctx结束
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	_, err = ParallelMap(ctx, 2, inputs, func(ctx context.Context, input int) (string, error) {
+		return strconv.Itoa(input), nil
+	})
+	assert.Equal(t, context.Canceled, err)
+
+	// 空
+	results, err = ParallelMap(context.TODO(), 2, []int{}, func(ctx context.Context, input int) (string, error) {
+		return strconv.Itoa(input), nil
+	})
+	assert.Nil(t, err)
+	assert.Empty(t, results)
+}
+
+func TestParallelMapErrors(t *testing.T) {
+	inputs := []int{1, 2, 3, 4}
+	wantErr := errors.New("wow")
+
+	results, errs := ParallelMapErrors(context.TODO(), 2, inputs, func(ctx context.Context, input int) (int, error) {
+		if input%2 == 0 {
+			return 0, wantErr
+		}
+		return input * 10, nil
+	})
+	assert.Equal(t, []int{10, 0, 30, 0}, results)
+	assert.Equal(t, []error{nil, wantErr, nil, wantErr}, errs)
+}
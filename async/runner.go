@@ -0,0 +1,112 @@
+package async
+
+import "context"
+
+// defaultRunner 包级函数使用的Runner。没有选项。
+var defaultRunner Runner
+
+// Runner 按选项串行或者并行执行一组函数。
+// 零值可用，行为同包级函数。
+type Runner struct {
+	opts options
+}
+
+// NewRunner 新建Runner。
+func NewRunner(opts ...Option) *Runner {
+	return &Runner{opts: newOptions(opts...)}
+}
+
+// Series 同包级函数Series，使用Runner的选项。
+func (r *Runner) Series(funcs ...func() error) error {
+	return r.SeriesCtx(context.Background(), withoutContext(funcs)...)
+}
+
+// SeriesCtx 同包级函数SeriesCtx，使用Runner的选项。
+func (r *Runner) SeriesCtx(ctx context.Context, funcs ...func(ctx context.Context) error) error {
+	childCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var err error
+	for index, f := range funcs {
+		err = ctx.Err()
+		if err != nil {
+			break
+		}
+
+		if r.opts.panicError {
+			var panicErr *PanicError
+			panicErr, err = call(childCtx, index, f)
+			if panicErr != nil {
+				err = panicErr
+			}
+		} else {
+			err = f(childCtx)
+		}
+		if err != nil {
+			break
+		}
+	}
+	return err
+}
+
+// Parallel 同包级函数Parallel，使用Runner的选项。
+func (r *Runner) Parallel(funcs ...func() error) error {
+	return r.ParallelLimit(len(funcs), funcs...)
+}
+
+// ParallelLimit 同包级函数ParallelLimit，使用Runner的选项。
+func (r *Runner) ParallelLimit(limit int, funcs ...func() error) error {
+	if limit <= 0 {
+		// 保持原有的行为：不执行任何函数
+		return nil
+	}
+	return r.ParallelLimitCtx(context.Background(), limit, withoutContext(funcs)...)
+}
+
+// ParallelCtx 同包级函数ParallelCtx，使用Runner的选项。
+func (r *Runner) ParallelCtx(ctx context.Context, funcs ...func(ctx context.Context) error) error {
+	return r.ParallelLimitCtx(ctx, len(funcs), funcs...)
+}
+
+// ParallelLimitCtx 同包级函数ParallelLimitCtx，使用Runner的选项。
+func (r *Runner) ParallelLimitCtx(ctx context.Context, limit int, funcs ...func(ctx context.Context) error) error {
+	errs, executed := parallel(ctx, parallelLimit(limit, len(funcs)), false, r.opts, funcs)
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	for _, ok := range executed {
+		if !ok {
+			// 有函数因为上级ctx结束而未执行
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+// ParallelAll 同包级函数ParallelAll，使用Runner的选项。
+func (r *Runner) ParallelAll(limit int, funcs ...func() error) error {
+	return r.ParallelAllCtx(context.Background(), limit, withoutContext(funcs)...)
+}
+
+// ParallelAllCtx 同包级函数ParallelAllCtx，使用Runner的选项。
+// 设置WithPanicError时，panic的函数不影响其它函数的执行，它的错误是*PanicError。
+func (r *Runner) ParallelAllCtx(ctx context.Context, limit int, funcs ...func(ctx context.Context) error) error {
+	errs, executed := parallel(ctx, parallelLimit(limit, len(funcs)), true, r.opts, funcs)
+
+	var multiErr MultiError
+	for index, err := range errs {
+		if !executed[index] {
+			err = ctx.Err()
+		}
+		if err != nil {
+			multiErr.Errors = append(multiErr.Errors, &TaskError{Index: index, Err: err})
+		}
+	}
+	if len(multiErr.Errors) > 0 {
+		return &multiErr
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package async
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunner_PanicError(t *testing.T) {
+	runner := NewRunner(WithPanicError())
+
+	// Series
+	err := runner.Series(func() error {
+		return nil
+	}, func() error {
+		panic("wow")
+	}, func() error {
+		t.Error("should not run")
+		return nil
+	})
+	var panicErr *PanicError
+	if assert.True(t, errors.As(err, &panicErr)) {
+		assert.Equal(t, "wow", panicErr.Value)
+		assert.Equal(t, 1, panicErr.Index)
+		assert.True(t, strings.Contains(string(panicErr.Stack), "TestRunner_PanicError"))
+	}
+	assert.True(t, errors.Is(err, ErrPanicked))
+
+	// Parallel
+	err = runner.Parallel(func() error {
+		return nil
+	}, func() error {
+		panic("wow")
+	})
+	if assert.True(t, errors.As(err, &panicErr)) {
+		assert.Equal(t, "wow", panicErr.Value)
+		assert.Equal(t, 1, panicErr.Index)
+	}
+
+	// ParallelLimitCtx，panic后取消派生的ctx
+	err = runner.ParallelLimitCtx(context.TODO(), 2, func(ctx context.Context) error {
+		panic("wow")
+	}, func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	})
+	assert.True(t, errors.Is(err, ErrPanicked))
+
+	// ParallelAll，panic不影响其它函数
+	err = runner.ParallelAll(1, func() error {
+		panic("wow")
+	}, func() error {
+		panic("wow")
+	}, func() error {
+		return nil
+	})
+	var multiErr *MultiError
+	if assert.True(t, errors.As(err, &multiErr)) && assert.Len(t, multiErr.Errors, 2) {
+		assert.Equal(t, 0, multiErr.Errors[0].Index)
+		assert.Equal(t, 1, multiErr.Errors[1].Index)
+	}
+	assert.True(t, errors.Is(err, ErrPanicked))
+}
+
+func TestRunner_Panic(t *testing.T) {
+	// 没有选项时，再次panic
+	var runner Runner
+	assert.PanicsWithValue(t, "wow", func() {
+		_ = runner.Series(func() error {
+			panic("wow")
+		})
+	})
+	assert.PanicsWithValue(t, "wow", func() {
+		_ = runner.Parallel(func() error {
+			panic("wow")
+		})
+	})
+}
+
+func TestGraceful_PanicError(t *testing.T) {
+	graceful := DefaultGraceful.NewBranch("panic", WithPanicError())
+
+	err := graceful.RunE(func() error {
+		panic("wow")
+	})
+	var panicErr *PanicError
+	if assert.True(t, errors.As(err, &panicErr)) {
+		assert.Equal(t, "wow", panicErr.Value)
+		assert.Equal(t, 0, panicErr.Index)
+	}
+
+	func() {
+		defer func() {
+			r := recover()
+			assert.True(t, errors.As(r.(error), &panicErr))
+		}()
+		graceful.Run(func() {
+			panic("wow")
+		})
+	}()
+	assert.Empty(t, graceful.BusyBranches())
+}
+
+func TestNewGraceful_PanicError(t *testing.T) {
+	graceful := NewGraceful("root", WithPanicError())
+
+	err := graceful.RunE(func() error {
+		panic("wow")
+	})
+	var panicErr *PanicError
+	if assert.True(t, errors.As(err, &panicErr)) {
+		assert.Equal(t, "wow", panicErr.Value)
+	}
+
+	// 分支不继承选项
+	branch := graceful.NewBranch("branch")
+	assert.PanicsWithValue(t, "wow", func() {
+		_ = branch.RunE(func() error {
+			panic("wow")
+		})
+	})
+	assert.Nil(t, graceful.Wait(context.TODO()))
+}
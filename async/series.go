@@ -1,5 +1,7 @@
 package async
 
+import "context"
+
 // Series 串行执行一组函数，直至出错或者执行完。
 func Series(funcs ...func() error) error {
 	var err error
@@ -11,3 +13,10 @@ func Series(funcs ...func() error) error {
 	}
 	return err
 }
+
+// SeriesCtx 串行执行一组函数，直至出错、ctx结束或者执行完。
+// 每个函数接收派生的ctx，函数返回错误或者panic时被取消。
+// 如果ctx结束，后面的函数不再执行，返回ctx的错误。
+func SeriesCtx(ctx context.Context, funcs ...func(ctx context.Context) error) error {
+	return defaultRunner.SeriesCtx(ctx, funcs...)
+}
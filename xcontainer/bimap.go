@@ -0,0 +1,117 @@
+package xcontainer
+
+import "errors"
+
+// ErrBiMapConflict key已经映射到其它value，或者value已经映射到其它key。
+var ErrBiMapConflict = errors.New("key or value already mapped")
+
+// BiMapPolicy BiMap.Put遇到key或者value已经有其它映射时的处理方式。
+// 两个方向的处理相同，Inverse视图遵守同样的规则。
+type BiMapPolicy int
+
+const (
+	// BiMapReject 拒绝写入，Put返回ErrBiMapConflict。
+	BiMapReject BiMapPolicy = iota
+
+	// BiMapReplace 删除key和value原有的映射，再写入。
+	BiMapReplace
+)
+
+// BiMap 双向映射的容器。key和value一一对应。
+// 非并发安全。
+type BiMap[K comparable, V comparable] struct {
+	forward  map[K]V
+	backward map[V]K
+
+	policy BiMapPolicy
+}
+
+// NewBiMap 新建一个BiMap。
+// policy 指定Put遇到key或者value已经有其它映射时的处理方式。
+func NewBiMap[K comparable, V comparable](policy BiMapPolicy) *BiMap[K, V] {
+	return &BiMap[K, V]{
+		forward:  make(map[K]V),
+		backward: make(map[V]K),
+		policy:   policy,
+	}
+}
+
+// Put 写入kv对。kv对已经存在时，不做修改。
+// 如果key已经映射到其它value，或者value已经映射到其它key，按policy处理。
+func (m *BiMap[K, V]) Put(key K, value V) error {
+	oldValue, keyFound := m.forward[key]
+	oldKey, valueFound := m.backward[value]
+	if valueFound && oldKey == key {
+		return nil
+	}
+	if (keyFound || valueFound) && m.policy != BiMapReplace {
+		return ErrBiMapConflict
+	}
+
+	if keyFound {
+		delete(m.backward, oldValue)
+	}
+	if valueFound {
+		delete(m.forward, oldKey)
+	}
+
+	m.forward[key] = value
+	m.backward[value] = key
+	return nil
+}
+
+// GetByKey 根据key获取value。
+func (m *BiMap[K, V]) GetByKey(key K) (value V, ok bool) {
+	value, ok = m.forward[key]
+	return value, ok
+}
+
+// GetByValue 根据value获取key。
+func (m *BiMap[K, V]) GetByValue(value V) (key K, ok bool) {
+	key, ok = m.backward[value]
+	return key, ok
+}
+
+// DeleteByKey 根据key删除kv对。返回被删除的value。
+func (m *BiMap[K, V]) DeleteByKey(key K) (value V, ok bool) {
+	value, ok = m.forward[key]
+	if ok {
+		delete(m.forward, key)
+		delete(m.backward, value)
+	}
+	return value, ok
+}
+
+// DeleteByValue 根据value删除kv对。返回被删除的key。
+func (m *BiMap[K, V]) DeleteByValue(value V) (key K, ok bool) {
+	key, ok = m.backward[value]
+	if ok {
+		delete(m.backward, value)
+		delete(m.forward, key)
+	}
+	return key, ok
+}
+
+// Inverse 返回key和value互换的视图。
+// 视图和原BiMap共享数据，修改任意一方，另一方可见。
+func (m *BiMap[K, V]) Inverse() *BiMap[V, K] {
+	return &BiMap[V, K]{
+		forward:  m.backward,
+		backward: m.forward,
+		policy:   m.policy,
+	}
+}
+
+// Len kv对的个数。
+func (m *BiMap[K, V]) Len() int {
+	return len(m.forward)
+}
+
+// Range 遍历全部kv对。顺序不确定。
+func (m *BiMap[K, V]) Range(f func(key K, value V) (stopIteration bool)) {
+	for key, value := range m.forward {
+		if f(key, value) {
+			return
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package xcontainer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBiMap_Put(t *testing.T) {
+	m := NewBiMap[int, string](BiMapReject)
+
+	assert.Nil(t, m.Put(1, "one"))
+	assert.Nil(t, m.Put(2, "two"))
+	assert.Nil(t, m.Put(1, "one"))
+
+	v, ok := m.GetByKey(1)
+	assert.True(t, ok)
+	assert.Equal(t, "one", v)
+	k, ok := m.GetByValue("two")
+	assert.True(t, ok)
+	assert.Equal(t, 2, k)
+
+	// value已经映射到其它key
+	assert.ErrorIs(t, m.Put(3, "one"), ErrBiMapConflict)
+	_, ok = m.GetByKey(3)
+	assert.False(t, ok)
+
+	// key已经映射到其它value
+	assert.ErrorIs(t, m.Put(1, "uno"), ErrBiMapConflict)
+	v, _ = m.GetByKey(1)
+	assert.Equal(t, "one", v)
+	_, ok = m.GetByValue("uno")
+	assert.False(t, ok)
+
+	// 删除后更新key的value
+	m.DeleteByKey(1)
+	assert.Nil(t, m.Put(1, "uno"))
+	k, _ = m.GetByValue("uno")
+	assert.Equal(t, 1, k)
+	assert.Equal(t, 2, m.Len())
+}
+
+func TestBiMap_PutReplace(t *testing.T) {
+	m := NewBiMap[int, string](BiMapReplace)
+
+	assert.Nil(t, m.Put(1, "one"))
+	assert.Nil(t, m.Put(2, "two"))
+
+	// 替换value原有的映射
+	assert.Nil(t, m.Put(3, "one"))
+	_, ok := m.GetByKey(1)
+	assert.False(t, ok)
+	k, _ := m.GetByValue("one")
+	assert.Equal(t, 3, k)
+
+	// key和value都已经存在
+	assert.Nil(t, m.Put(3, "two"))
+	_, ok = m.GetByKey(2)
+	assert.False(t, ok)
+	_, ok = m.GetByValue("one")
+	assert.False(t, ok)
+	v, _ := m.GetByKey(3)
+	assert.Equal(t, "two", v)
+	assert.Equal(t, 1, m.Len())
+}
+
+func TestBiMap_Delete(t *testing.T) {
+	m := NewBiMap[int, string](BiMapReject)
+	m.Put(1, "one")
+	m.Put(2, "two")
+
+	v, ok := m.DeleteByKey(1)
+	assert.True(t, ok)
+	assert.Equal(t, "one", v)
+	_, ok = m.GetByValue("one")
+	assert.False(t, ok)
+
+	k, ok := m.DeleteByValue("two")
+	assert.True(t, ok)
+	assert.Equal(t, 2, k)
+	_, ok = m.GetByKey(2)
+	assert.False(t, ok)
+
+	_, ok = m.DeleteByKey(1)
+	assert.False(t, ok)
+	assert.Equal(t, 0, m.Len())
+}
+
+func TestBiMap_Inverse(t *testing.T) {
+	m := NewBiMap[int, string](BiMapReject)
+	m.Put(1, "one")
+
+	inverse := m.Inverse()
+	k, ok := inverse.GetByKey("one")
+	assert.True(t, ok)
+	assert.Equal(t, 1, k)
+
+	// 通过视图修改
+	assert.Nil(t, inverse.Put("two", 2))
+	v, _ := m.GetByKey(2)
+	assert.Equal(t, "two", v)
+	assert.ErrorIs(t, inverse.Put("three", 1), ErrBiMapConflict)
+
+	// 两个方向的规则相同
+	assert.ErrorIs(t, m.Put(1, "uno"), ErrBiMapConflict)
+	assert.ErrorIs(t, inverse.Put("uno", 1), ErrBiMapConflict)
+	assert.ErrorIs(t, m.Put(3, "one"), ErrBiMapConflict)
+	assert.ErrorIs(t, inverse.Put("one", 3), ErrBiMapConflict)
+
+	inverse.DeleteByKey("one")
+	_, ok = m.GetByKey(1)
+	assert.False(t, ok)
+	assert.Equal(t, 1, m.Len())
+}
@@ -0,0 +1,336 @@
+package xcontainer
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"sync/atomic"
+)
+
+// ErrIncompatibleFilter 两个过滤器的位数或者哈希函数个数不同。
+var ErrIncompatibleFilter = errors.New("incompatible filter")
+
+// ErrInvalidFilterData 序列化的数据不合法。
+var ErrInvalidFilterData = errors.New("invalid filter data")
+
+const (
+	bloomKindBits     byte = 1
+	bloomKindCounting byte = 2
+
+	bloomHeaderSize = 1 + 8 + 8
+
+	// maxBloomHashes 哈希函数个数的上限。
+	// 误判率大于2^-256时，计算的k不超过它；反序列化时拒绝更大的k，以免Test执行过久。
+	maxBloomHashes = 256
+)
+
+// bloomParams 根据预计的元素个数和误判率，计算位数m和哈希函数个数k。
+func bloomParams(expectedItems int, falsePositiveRate float64) (m, k uint64) {
+	if falsePositiveRate <= 0 || falsePositiveRate >= 1 {
+		panic("falsePositiveRate must be in (0, 1)")
+	}
+	if expectedItems < 1 {
+		expectedItems = 1
+	}
+
+	n := float64(expectedItems)
+	m = uint64(math.Ceil(-n * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2)))
+	if m < 1 {
+		m = 1
+	}
+	k = uint64(math.Round(float64(m) / n * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+	if k > maxBloomHashes {
+		k = maxBloomHashes
+	}
+	return m, k
+}
+
+// bloomHash 计算双重哈希的两个基础哈希值。
+func bloomHash(data []byte) (h1, h2 uint64) {
+	// FNV-1a
+	h1 = 14695981039346656037
+	for _, c := range data {
+		h1 ^= uint64(c)
+		h1 *= 1099511628211
+	}
+
+	// splitmix64的终结函数
+	h2 = h1 + 0x9e3779b97f4a7c15
+	h2 = (h2 ^ (h2 >> 30)) * 0xbf58476d1ce4e5b9
+	h2 = (h2 ^ (h2 >> 27)) * 0x94d049bb133111eb
+	h2 ^= h2 >> 31
+	return h1, h2 | 1
+}
+
+// bloomLocations 遍历data对应的k个位置。
+func bloomLocations(data []byte, m, k uint64, f func(location uint64) (stopIteration bool)) {
+	h1, h2 := bloomHash(data)
+	for i := uint64(0); i < k; i++ {
+		if f((h1 + i*h2) % m) {
+			return
+		}
+	}
+}
+
+func marshalBloomHeader(kind byte, m, k uint64, payloadSize int) []byte {
+	data := make([]byte, bloomHeaderSize, bloomHeaderSize+payloadSize)
+	data[0] = kind
+	binary.BigEndian.PutUint64(data[1:], m)
+	binary.BigEndian.PutUint64(data[9:], k)
+	return data
+}
+
+func unmarshalBloomHeader(kind byte, data []byte) (m, k uint64, payload []byte, err error) {
+	if len(data) < bloomHeaderSize || data[0] != kind {
+		return 0, 0, nil, ErrInvalidFilterData
+	}
+	m = binary.BigEndian.Uint64(data[1:])
+	k = binary.BigEndian.Uint64(data[9:])
+	if m == 0 || k == 0 || k > maxBloomHashes {
+		return 0, 0, nil, ErrInvalidFilterData
+	}
+	return m, k, data[bloomHeaderSize:], nil
+}
+
+// BloomFilter 布隆过滤器。
+// Test返回false时，元素一定不存在；返回true时，元素可能存在。
+// 非并发安全。
+type BloomFilter struct {
+	words []uint64
+	m     uint64
+	k     uint64
+}
+
+// NewBloomFilter 根据预计的元素个数和误判率，新建一个BloomFilter。
+func NewBloomFilter(expectedItems int, falsePositiveRate float64) *BloomFilter {
+	m, k := bloomParams(expectedItems, falsePositiveRate)
+	return &BloomFilter{
+		words: make([]uint64, (m+63)/64),
+		m:     m,
+		k:     k,
+	}
+}
+
+// Add 添加元素。
+func (f *BloomFilter) Add(data []byte) {
+	bloomLocations(data, f.m, f.k, func(location uint64) (stopIteration bool) {
+		f.words[location/64] |= 1 << (location % 64)
+		return false
+	})
+}
+
+// AddString 添加字符串元素。
+func (f *BloomFilter) AddString(s string) {
+	f.Add([]byte(s))
+}
+
+// Test 元素是否可能存在。
+func (f *BloomFilter) Test(data []byte) bool {
+	exists := true
+	bloomLocations(data, f.m, f.k, func(location uint64) (stopIteration bool) {
+		if f.words[location/64]&(1<<(location%64)) == 0 {
+			exists = false
+			return true
+		}
+		return false
+	})
+	return exists
+}
+
+// TestString 字符串元素是否可能存在。
+func (f *BloomFilter) TestString(s string) bool {
+	return f.Test([]byte(s))
+}
+
+// Cap 位数。
+func (f *BloomFilter) Cap() uint64 {
+	return f.m
+}
+
+// K 哈希函数个数。
+func (f *BloomFilter) K() uint64 {
+	return f.k
+}
+
+// Union 合并other的元素。两个过滤器的位数和哈希函数个数必须相同。
+func (f *BloomFilter) Union(other *BloomFilter) error {
+	if f.m != other.m || f.k != other.k {
+		return ErrIncompatibleFilter
+	}
+	for i, word := range other.words {
+		f.words[i] |= word
+	}
+	return nil
+}
+
+// Intersect 只保留同时在other中的元素。两个过滤器的位数和哈希函数个数必须相同。
+func (f *BloomFilter) Intersect(other *BloomFilter) error {
+	if f.m != other.m || f.k != other.k {
+		return ErrIncompatibleFilter
+	}
+	for i, word := range other.words {
+		f.words[i] &= word
+	}
+	return nil
+}
+
+// Clear 清理全部元素。
+func (f *BloomFilter) Clear() {
+	for i := range f.words {
+		f.words[i] = 0
+	}
+}
+
+// MarshalBinary 序列化。
+func (f *BloomFilter) MarshalBinary() ([]byte, error) {
+	data := marshalBloomHeader(bloomKindBits, f.m, f.k, len(f.words)*8)
+	for _, word := range f.words {
+		data = binary.BigEndian.AppendUint64(data, word)
+	}
+	return data, nil
+}
+
+// UnmarshalBinary 反序列化。
+func (f *BloomFilter) UnmarshalBinary(data []byte) error {
+	m, k, payload, err := unmarshalBloomHeader(bloomKindBits, data)
+	if err != nil {
+		return err
+	}
+	if m > uint64(len(payload))*8 { // 先检查，以免计算wordCount时溢出
+		return ErrInvalidFilterData
+	}
+	wordCount := (m + 63) / 64
+	if uint64(len(payload)) != wordCount*8 {
+		return ErrInvalidFilterData
+	}
+
+	f.m, f.k = m, k
+	f.words = make([]uint64, wordCount)
+	for i := range f.words {
+		f.words[i] = binary.BigEndian.Uint64(payload[i*8:])
+	}
+	return nil
+}
+
+// ConcurrentBloomFilter 并发安全的布隆过滤器。基于原子操作，不加锁。
+// 序列化格式和BloomFilter相同。
+type ConcurrentBloomFilter struct {
+	words []uint64
+	m     uint64
+	k     uint64
+}
+
+// NewConcurrentBloomFilter 根据预计的元素个数和误判率，新建一个ConcurrentBloomFilter。
+func NewConcurrentBloomFilter(expectedItems int, falsePositiveRate float64) *ConcurrentBloomFilter {
+	m, k := bloomParams(expectedItems, falsePositiveRate)
+	return &ConcurrentBloomFilter{
+		words: make([]uint64, (m+63)/64),
+		m:     m,
+		k:     k,
+	}
+}
+
+// orWord 原子地将mask合并到第i个字。
+func (f *ConcurrentBloomFilter) orWord(i uint64, mask uint64) {
+	for {
+		old := atomic.LoadUint64(&f.words[i])
+		if old&mask == mask || atomic.CompareAndSwapUint64(&f.words[i], old, old|mask) {
+			return
+		}
+	}
+}
+
+// andWord 原子地将第i个字和mask按位与。
+func (f *ConcurrentBloomFilter) andWord(i uint64, mask uint64) {
+	for {
+		old := atomic.LoadUint64(&f.words[i])
+		if old&mask == old || atomic.CompareAndSwapUint64(&f.words[i], old, old&mask) {
+			return
+		}
+	}
+}
+
+// Add 添加元素。
+func (f *ConcurrentBloomFilter) Add(data []byte) {
+	bloomLocations(data, f.m, f.k, func(location uint64) (stopIteration bool) {
+		f.orWord(location/64, 1<<(location%64))
+		return false
+	})
+}
+
+// AddString 添加字符串元素。
+func (f *ConcurrentBloomFilter) AddString(s string) {
+	f.Add([]byte(s))
+}
+
+// Test 元素是否可能存在。
+func (f *ConcurrentBloomFilter) Test(data []byte) bool {
+	exists := true
+	bloomLocations(data, f.m, f.k, func(location uint64) (stopIteration bool) {
+		if atomic.LoadUint64(&f.words[location/64])&(1<<(location%64)) == 0 {
+			exists = false
+			return true
+		}
+		return false
+	})
+	return exists
+}
+
+// TestString 字符串元素是否可能存在。
+func (f *ConcurrentBloomFilter) TestString(s string) bool {
+	return f.Test([]byte(s))
+}
+
+// Cap 位数。
+func (f *ConcurrentBloomFilter) Cap() uint64 {
+	return f.m
+}
+
+// K 哈希函数个数。
+func (f *ConcurrentBloomFilter) K() uint64 {
+	return f.k
+}
+
+// Union 合并other的元素。两个过滤器的位数和哈希函数个数必须相同。
+func (f *ConcurrentBloomFilter) Union(other *ConcurrentBloomFilter) error {
+	if f.m != other.m || f.k != other.k {
+		return ErrIncompatibleFilter
+	}
+	for i := range other.words {
+		f.orWord(uint64(i), atomic.LoadUint64(&other.words[i]))
+	}
+	return nil
+}
+
+// Intersect 只保留同时在other中的元素。两个过滤器的位数和哈希函数个数必须相同。
+func (f *ConcurrentBloomFilter) Intersect(other *ConcurrentBloomFilter) error {
+	if f.m != other.m || f.k != other.k {
+		return ErrIncompatibleFilter
+	}
+	for i := range other.words {
+		f.andWord(uint64(i), atomic.LoadUint64(&other.words[i]))
+	}
+	return nil
+}
+
+// MarshalBinary 序列化。和并发的Add同时执行时，结果是某个时刻的近似快照。
+func (f *ConcurrentBloomFilter) MarshalBinary() ([]byte, error) {
+	data := marshalBloomHeader(bloomKindBits, f.m, f.k, len(f.words)*8)
+	for i := range f.words {
+		data = binary.BigEndian.AppendUint64(data, atomic.LoadUint64(&f.words[i]))
+	}
+	return data, nil
+}
+
+// UnmarshalBinary 反序列化。不能和其它方法并发执行。
+func (f *ConcurrentBloomFilter) UnmarshalBinary(data []byte) error {
+	var bf BloomFilter
+	if err := bf.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	f.words, f.m, f.k = bf.words, bf.m, bf.k
+	return nil
+}
@@ -0,0 +1,152 @@
+package xcontainer
+
+import (
+	"encoding/binary"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBloomFilter(t *testing.T) {
+	f := NewBloomFilter(1000, 0.01)
+
+	for i := 0; i < 1000; i++ {
+		f.AddString(strconv.Itoa(i))
+	}
+	for i := 0; i < 1000; i++ {
+		assert.True(t, f.TestString(strconv.Itoa(i)), "item: %d", i)
+	}
+
+	var falsePositives int
+	for i := 1000; i < 11000; i++ {
+		if f.TestString(strconv.Itoa(i)) {
+			falsePositives++
+		}
+	}
+	assert.Less(t, float64(falsePositives)/10000, 0.02)
+
+	f.Clear()
+	assert.False(t, f.TestString("1"))
+}
+
+func TestBloomFilter_Marshal(t *testing.T) {
+	f := NewBloomFilter(100, 0.01)
+	f.AddString("hello")
+
+	data, err := f.MarshalBinary()
+	assert.Nil(t, err)
+
+	var f2 BloomFilter
+	if assert.Nil(t, f2.UnmarshalBinary(data)) {
+		assert.True(t, f2.TestString("hello"))
+		assert.False(t, f2.TestString("world"))
+		assert.Equal(t, f.Cap(), f2.Cap())
+		assert.Equal(t, f.K(), f2.K())
+	}
+
+	assert.ErrorIs(t, f2.UnmarshalBinary(data[:len(data)-1]), ErrInvalidFilterData)
+	assert.ErrorIs(t, f2.UnmarshalBinary(nil), ErrInvalidFilterData)
+
+	var cf CountingBloomFilter
+	assert.ErrorIs(t, cf.UnmarshalBinary(data), ErrInvalidFilterData)
+}
+
+func TestBloomFilter_UnionIntersect(t *testing.T) {
+	a := NewBloomFilter(100, 0.01)
+	b := NewBloomFilter(100, 0.01)
+	a.AddString("a")
+	a.AddString("both")
+	b.AddString("b")
+	b.AddString("both")
+
+	union := NewBloomFilter(100, 0.01)
+	assert.Nil(t, union.Union(a))
+	assert.Nil(t, union.Union(b))
+	assert.True(t, union.TestString("a"))
+	assert.True(t, union.TestString("b"))
+	assert.True(t, union.TestString("both"))
+
+	assert.Nil(t, a.Intersect(b))
+	assert.True(t, a.TestString("both"))
+	assert.False(t, a.TestString("a"))
+
+	assert.ErrorIs(t, a.Union(NewBloomFilter(1000, 0.01)), ErrIncompatibleFilter)
+	assert.ErrorIs(t, a.Intersect(NewBloomFilter(100, 0.001)), ErrIncompatibleFilter)
+}
+
+func TestConcurrentBloomFilter(t *testing.T) {
+	f := NewConcurrentBloomFilter(10000, 0.01)
+
+	var wg sync.WaitGroup
+	wg.Add(10)
+	for g := 0; g < 10; g++ {
+		go func(g int) {
+			defer wg.Done()
+
+			for i := g * 1000; i < (g+1)*1000; i++ {
+				f.AddString(strconv.Itoa(i))
+				assert.True(t, f.TestString(strconv.Itoa(i)))
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	for i := 0; i < 10000; i++ {
+		assert.True(t, f.TestString(strconv.Itoa(i)), "item: %d", i)
+	}
+
+	// 和BloomFilter的序列化格式相同
+	data, err := f.MarshalBinary()
+	assert.Nil(t, err)
+	var bf BloomFilter
+	if assert.Nil(t, bf.UnmarshalBinary(data)) {
+		assert.True(t, bf.TestString("1"))
+	}
+	var f2 ConcurrentBloomFilter
+	if assert.Nil(t, f2.UnmarshalBinary(data)) {
+		assert.True(t, f2.TestString("1"))
+	}
+
+	other := NewConcurrentBloomFilter(10000, 0.01)
+	other.AddString("other")
+	assert.Nil(t, f.Union(other))
+	assert.True(t, f.TestString("other"))
+	assert.Nil(t, f.Intersect(other))
+	assert.True(t, f.TestString("other"))
+	assert.False(t, f.TestString("1"))
+}
+
+// malformedBloomData 构造序列化的数据。
+func malformedBloomData(kind byte, m, k uint64, payloadSize int) []byte {
+	data := make([]byte, bloomHeaderSize+payloadSize)
+	data[0] = kind
+	binary.BigEndian.PutUint64(data[1:], m)
+	binary.BigEndian.PutUint64(data[9:], k)
+	return data
+}
+
+func TestBloomFilter_UnmarshalMalformed(t *testing.T) {
+	var f BloomFilter
+	// m接近MaxUint64，计算字数时溢出
+	assert.ErrorIs(t, f.UnmarshalBinary(malformedBloomData(bloomKindBits, ^uint64(0), 3, 0)), ErrInvalidFilterData)
+	assert.ErrorIs(t, f.UnmarshalBinary(malformedBloomData(bloomKindBits, ^uint64(0)-62, 3, 8)), ErrInvalidFilterData)
+	// m大于数据的位数
+	assert.ErrorIs(t, f.UnmarshalBinary(malformedBloomData(bloomKindBits, 65, 3, 8)), ErrInvalidFilterData)
+	// k过大
+	assert.ErrorIs(t, f.UnmarshalBinary(malformedBloomData(bloomKindBits, 64, ^uint64(0), 8)), ErrInvalidFilterData)
+	assert.ErrorIs(t, f.UnmarshalBinary(malformedBloomData(bloomKindBits, 64, maxBloomHashes+1, 8)), ErrInvalidFilterData)
+
+	// 合法的数据
+	if assert.Nil(t, f.UnmarshalBinary(malformedBloomData(bloomKindBits, 64, maxBloomHashes, 8))) {
+		assert.False(t, f.TestString("hello"))
+	}
+
+	var cf ConcurrentBloomFilter
+	assert.ErrorIs(t, cf.UnmarshalBinary(malformedBloomData(bloomKindBits, ^uint64(0), 3, 0)), ErrInvalidFilterData)
+
+	// 极小的误判率，k不超过上限
+	_, k := bloomParams(1, 1e-300)
+	assert.Equal(t, uint64(maxBloomHashes), k)
+}
@@ -0,0 +1,157 @@
+package xcontainer
+
+import "math"
+
+// CountingBloomFilter 计数布隆过滤器。支持删除元素。
+// 每个位置是一个8位的计数器，计数器达到上限后不再增减。
+// 非并发安全。
+type CountingBloomFilter struct {
+	counters []uint8
+	m        uint64
+	k        uint64
+}
+
+// NewCountingBloomFilter 根据预计的元素个数和误判率，新建一个CountingBloomFilter。
+func NewCountingBloomFilter(expectedItems int, falsePositiveRate float64) *CountingBloomFilter {
+	m, k := bloomParams(expectedItems, falsePositiveRate)
+	return &CountingBloomFilter{
+		counters: make([]uint8, m),
+		m:        m,
+		k:        k,
+	}
+}
+
+// Add 添加元素。
+func (f *CountingBloomFilter) Add(data []byte) {
+	bloomLocations(data, f.m, f.k, func(location uint64) (stopIteration bool) {
+		if f.counters[location] < math.MaxUint8 {
+			f.counters[location]++
+		}
+		return false
+	})
+}
+
+// AddString 添加字符串元素。
+func (f *CountingBloomFilter) AddString(s string) {
+	f.Add([]byte(s))
+}
+
+// Remove 删除元素。元素可能不存在时，不做修改，返回false。
+// 只应该删除添加过的元素，否则会删除其它元素。
+func (f *CountingBloomFilter) Remove(data []byte) bool {
+	if !f.Test(data) {
+		return false
+	}
+	bloomLocations(data, f.m, f.k, func(location uint64) (stopIteration bool) {
+		if f.counters[location] < math.MaxUint8 { // 达到上限的计数器已经不准确，不再减少
+			f.counters[location]--
+		}
+		return false
+	})
+	return true
+}
+
+// RemoveString 删除字符串元素。
+func (f *CountingBloomFilter) RemoveString(s string) bool {
+	return f.Remove([]byte(s))
+}
+
+// Test 元素是否可能存在。
+func (f *CountingBloomFilter) Test(data []byte) bool {
+	exists := true
+	bloomLocations(data, f.m, f.k, func(location uint64) (stopIteration bool) {
+		if f.counters[location] == 0 {
+			exists = false
+			return true
+		}
+		return false
+	})
+	return exists
+}
+
+// TestString 字符串元素是否可能存在。
+func (f *CountingBloomFilter) TestString(s string) bool {
+	return f.Test([]byte(s))
+}
+
+// Cap 计数器个数。
+func (f *CountingBloomFilter) Cap() uint64 {
+	return f.m
+}
+
+// K 哈希函数个数。
+func (f *CountingBloomFilter) K() uint64 {
+	return f.k
+}
+
+// Union 合并other的元素，计数器相加。两个过滤器的计数器个数和哈希函数个数必须相同。
+func (f *CountingBloomFilter) Union(other *CountingBloomFilter) error {
+	if f.m != other.m || f.k != other.k {
+		return ErrIncompatibleFilter
+	}
+	for i, counter := range other.counters {
+		sum := int(f.counters[i]) + int(counter)
+		if sum > math.MaxUint8 {
+			sum = math.MaxUint8
+		}
+		f.counters[i] = uint8(sum)
+	}
+	return nil
+}
+
+// Intersect 只保留同时在other中的元素，计数器取较小值。两个过滤器的计数器个数和哈希函数个数必须相同。
+func (f *CountingBloomFilter) Intersect(other *CountingBloomFilter) error {
+	if f.m != other.m || f.k != other.k {
+		return ErrIncompatibleFilter
+	}
+	for i, counter := range other.counters {
+		if counter < f.counters[i] {
+			f.counters[i] = counter
+		}
+	}
+	return nil
+}
+
+// BloomFilter 转为不支持删除的BloomFilter。
+func (f *CountingBloomFilter) BloomFilter() *BloomFilter {
+	bf := &BloomFilter{
+		words: make([]uint64, (f.m+63)/64),
+		m:     f.m,
+		k:     f.k,
+	}
+	for i, counter := range f.counters {
+		if counter > 0 {
+			bf.words[i/64] |= 1 << (i % 64)
+		}
+	}
+	return bf
+}
+
+// Clear 清理全部元素。
+func (f *CountingBloomFilter) Clear() {
+	for i := range f.counters {
+		f.counters[i] = 0
+	}
+}
+
+// MarshalBinary 序列化。
+func (f *CountingBloomFilter) MarshalBinary() ([]byte, error) {
+	data := marshalBloomHeader(bloomKindCounting, f.m, f.k, len(f.counters))
+	data = append(data, f.counters...)
+	return data, nil
+}
+
+// UnmarshalBinary 反序列化。
+func (f *CountingBloomFilter) UnmarshalBinary(data []byte) error {
+	m, k, payload, err := unmarshalBloomHeader(bloomKindCounting, data)
+	if err != nil {
+		return err
+	}
+	if uint64(len(payload)) != m { // 每个计数器一个字节，m不会超过数据的长度
+		return ErrInvalidFilterData
+	}
+
+	f.m, f.k = m, k
+	f.counters = append([]uint8(nil), payload...)
+	return nil
+}
@@ -0,0 +1,90 @@
+package xcontainer
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCountingBloomFilter(t *testing.T) {
+	f := NewCountingBloomFilter(1000, 0.01)
+
+	for i := 0; i < 1000; i++ {
+		f.AddString(strconv.Itoa(i))
+	}
+	for i := 0; i < 1000; i++ {
+		assert.True(t, f.TestString(strconv.Itoa(i)), "item: %d", i)
+	}
+
+	// 删除一半
+	for i := 0; i < 500; i++ {
+		assert.True(t, f.RemoveString(strconv.Itoa(i)))
+	}
+	for i := 500; i < 1000; i++ {
+		assert.True(t, f.TestString(strconv.Itoa(i)), "item: %d", i)
+	}
+	var falsePositives int
+	for i := 0; i < 500; i++ {
+		if f.TestString(strconv.Itoa(i)) {
+			falsePositives++
+		}
+	}
+	assert.Less(t, falsePositives, 50)
+
+	// 重复添加
+	f.AddString("dup")
+	f.AddString("dup")
+	assert.True(t, f.RemoveString("dup"))
+	assert.True(t, f.TestString("dup"))
+
+	f.Clear()
+	assert.False(t, f.RemoveString("dup"))
+}
+
+func TestCountingBloomFilter_Marshal(t *testing.T) {
+	f := NewCountingBloomFilter(100, 0.01)
+	f.AddString("hello")
+
+	data, err := f.MarshalBinary()
+	assert.Nil(t, err)
+
+	var f2 CountingBloomFilter
+	if assert.Nil(t, f2.UnmarshalBinary(data)) {
+		assert.True(t, f2.TestString("hello"))
+		assert.True(t, f2.RemoveString("hello"))
+		assert.False(t, f2.TestString("hello"))
+	}
+	// 不影响原来的过滤器
+	assert.True(t, f.TestString("hello"))
+
+	assert.ErrorIs(t, f2.UnmarshalBinary(data[:len(data)-1]), ErrInvalidFilterData)
+	assert.ErrorIs(t, f2.UnmarshalBinary(malformedBloomData(bloomKindCounting, ^uint64(0), 3, 0)), ErrInvalidFilterData)
+	assert.ErrorIs(t, f2.UnmarshalBinary(malformedBloomData(bloomKindCounting, 8, ^uint64(0), 8)), ErrInvalidFilterData)
+}
+
+func TestCountingBloomFilter_UnionIntersect(t *testing.T) {
+	a := NewCountingBloomFilter(100, 0.01)
+	b := NewCountingBloomFilter(100, 0.01)
+	a.AddString("a")
+	a.AddString("both")
+	b.AddString("b")
+	b.AddString("both")
+
+	assert.Nil(t, a.Union(b))
+	assert.True(t, a.TestString("a"))
+	assert.True(t, a.TestString("b"))
+	assert.True(t, a.RemoveString("b"))
+	assert.True(t, a.TestString("both"))
+
+	assert.Nil(t, a.Intersect(b))
+	assert.True(t, a.TestString("both"))
+	assert.False(t, a.TestString("a"))
+
+	bf := b.BloomFilter()
+	assert.True(t, bf.TestString("b"))
+	assert.True(t, bf.TestString("both"))
+	assert.False(t, bf.TestString("a"))
+
+	assert.ErrorIs(t, a.Union(NewCountingBloomFilter(1000, 0.01)), ErrIncompatibleFilter)
+}
@@ -0,0 +1,321 @@
+package xcontainer
+
+// Ordered 支持<比较的类型。
+type Ordered interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64 |
+		~string
+}
+
+// Interval 闭区间[Start, End]和它的值。
+type Interval[T any, V any] struct {
+	Start T
+	End   T
+	Value V
+}
+
+// intervalNode 一个区间的节点。相同的区间共用节点，values按写入的顺序保存各自的值。
+type intervalNode[T any, V any] struct {
+	start  T
+	end    T
+	values []V
+
+	// maxEnd 子树中最大的End。
+	maxEnd T
+	height int
+
+	left  *intervalNode[T, V]
+	right *intervalNode[T, V]
+}
+
+// IntervalTree 区间树。支持查询与区间重叠、包含某点的全部区间。
+// 基于AVL树，按区间的Start、End排序。相同的区间可以写入多次，各自保留值，按写入的顺序排在一起。
+// 非并发安全。
+type IntervalTree[T any, V any] struct {
+	root *intervalNode[T, V]
+	size int
+
+	compare func(a, b T) int
+}
+
+// NewIntervalTree 新建一个IntervalTree。
+func NewIntervalTree[T Ordered, V any]() *IntervalTree[T, V] {
+	return NewIntervalTreeFunc[T, V](func(a, b T) int {
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		default:
+			return 0
+		}
+	})
+}
+
+// NewIntervalTreeFunc 新建一个使用compare比较端点的IntervalTree。
+// compare在a<b时返回负数，a>b时返回正数，相等时返回0。
+// 可用于time.Time等不支持<比较的类型。
+func NewIntervalTreeFunc[T any, V any](compare func(a, b T) int) *IntervalTree[T, V] {
+	return &IntervalTree[T, V]{
+		compare: compare,
+	}
+}
+
+func (t *IntervalTree[T, V]) compareInterval(start, end T, n *intervalNode[T, V]) int {
+	if c := t.compare(start, n.start); c != 0 {
+		return c
+	}
+	return t.compare(end, n.end)
+}
+
+func (t *IntervalTree[T, V]) maxOf(a, b T) T {
+	if t.compare(a, b) >= 0 {
+		return a
+	}
+	return b
+}
+
+func intervalNodeHeight[T any, V any](n *intervalNode[T, V]) int {
+	if n == nil {
+		return 0
+	}
+	return n.height
+}
+
+// update 重新计算节点的高度和maxEnd。
+func (t *IntervalTree[T, V]) update(n *intervalNode[T, V]) {
+	leftHeight, rightHeight := intervalNodeHeight(n.left), intervalNodeHeight(n.right)
+	if leftHeight > rightHeight {
+		n.height = leftHeight + 1
+	} else {
+		n.height = rightHeight + 1
+	}
+
+	n.maxEnd = n.end
+	if n.left != nil {
+		n.maxEnd = t.maxOf(n.maxEnd, n.left.maxEnd)
+	}
+	if n.right != nil {
+		n.maxEnd = t.maxOf(n.maxEnd, n.right.maxEnd)
+	}
+}
+
+func (t *IntervalTree[T, V]) rotateLeft(n *intervalNode[T, V]) *intervalNode[T, V] {
+	r := n.right
+	n.right = r.left
+	r.left = n
+	t.update(n)
+	t.update(r)
+	return r
+}
+
+func (t *IntervalTree[T, V]) rotateRight(n *intervalNode[T, V]) *intervalNode[T, V] {
+	l := n.left
+	n.left = l.right
+	l.right = n
+	t.update(n)
+	t.update(l)
+	return l
+}
+
+// balance 更新节点，并在失衡时旋转。
+func (t *IntervalTree[T, V]) balance(n *intervalNode[T, V]) *intervalNode[T, V] {
+	t.update(n)
+
+	factor := intervalNodeHeight(n.left) - intervalNodeHeight(n.right)
+	switch {
+	case factor > 1:
+		if intervalNodeHeight(n.left.left) < intervalNodeHeight(n.left.right) {
+			n.left = t.rotateLeft(n.left)
+		}
+		return t.rotateRight(n)
+	case factor < -1:
+		if intervalNodeHeight(n.right.right) < intervalNodeHeight(n.right.left) {
+			n.right = t.rotateRight(n.right)
+		}
+		return t.rotateLeft(n)
+	}
+	return n
+}
+
+func (t *IntervalTree[T, V]) insert(n *intervalNode[T, V], start, end T, value V) *intervalNode[T, V] {
+	if n == nil {
+		n = &intervalNode[T, V]{start: start, end: end, values: []V{value}}
+		t.update(n)
+		return n
+	}
+
+	switch c := t.compareInterval(start, end, n); {
+	case c < 0:
+		n.left = t.insert(n.left, start, end, value)
+	case c > 0:
+		n.right = t.insert(n.right, start, end, value)
+	default:
+		// 相同的区间，保留原有的值
+		n.values = append(n.values, value)
+		return n
+	}
+	return t.balance(n)
+}
+
+// Insert 写入闭区间[start, end]和它的值。
+// 区间已经存在时，不替换原有的值，两者都保留。例如同一时段的多个预约。
+// start不能大于end。
+func (t *IntervalTree[T, V]) Insert(start, end T, value V) {
+	if t.compare(start, end) > 0 {
+		panic("start must not be greater than end")
+	}
+
+	t.root = t.insert(t.root, start, end, value)
+	t.size++
+}
+
+// removeMin 删除子树中最小的节点。
+func (t *IntervalTree[T, V]) removeMin(n *intervalNode[T, V]) (node, successor *intervalNode[T, V]) {
+	if n.left == nil {
+		return n.right, n
+	}
+	n.left, successor = t.removeMin(n.left)
+	return t.balance(n), successor
+}
+
+// deleteFunc 删除区间中值满足match的部分。区间没有值时，删除节点。
+func (t *IntervalTree[T, V]) deleteFunc(n *intervalNode[T, V], start, end T, match func(value V) bool) (node *intervalNode[T, V], deleted []V) {
+	if n == nil {
+		return nil, nil
+	}
+
+	switch c := t.compareInterval(start, end, n); {
+	case c < 0:
+		n.left, deleted = t.deleteFunc(n.left, start, end, match)
+	case c > 0:
+		n.right, deleted = t.deleteFunc(n.right, start, end, match)
+	default:
+		var kept []V
+		for _, value := range n.values {
+			if match(value) {
+				deleted = append(deleted, value)
+			} else {
+				kept = append(kept, value)
+			}
+		}
+		if len(kept) > 0 {
+			n.values = kept
+			return n, deleted
+		}
+
+		if n.left == nil {
+			return n.right, deleted
+		}
+		if n.right == nil {
+			return n.left, deleted
+		}
+
+		var successor *intervalNode[T, V]
+		n.right, successor = t.removeMin(n.right)
+		successor.left, successor.right = n.left, n.right
+		return t.balance(successor), deleted
+	}
+	if len(deleted) == 0 {
+		return n, nil
+	}
+	return t.balance(n), deleted
+}
+
+// Delete 删除全部闭区间[start, end]。按写入的顺序返回被删除的值。
+func (t *IntervalTree[T, V]) Delete(start, end T) (values []V) {
+	return t.DeleteFunc(start, end, func(value V) bool {
+		return true
+	})
+}
+
+// DeleteFunc 删除值满足match的闭区间[start, end]。例如取消同一时段的其中一个预约。
+// 按写入的顺序返回被删除的值。
+func (t *IntervalTree[T, V]) DeleteFunc(start, end T, match func(value V) bool) (values []V) {
+	t.root, values = t.deleteFunc(t.root, start, end, match)
+	t.size -= len(values)
+	return values
+}
+
+// Get 按写入的顺序获取闭区间[start, end]的全部值。
+func (t *IntervalTree[T, V]) Get(start, end T) (values []V) {
+	n := t.root
+	for n != nil {
+		switch c := t.compareInterval(start, end, n); {
+		case c < 0:
+			n = n.left
+		case c > 0:
+			n = n.right
+		default:
+			return append([]V(nil), n.values...)
+		}
+	}
+	return nil
+}
+
+// each 按写入的顺序，对节点的每个值调用f。
+func (n *intervalNode[T, V]) each(f func(interval Interval[T, V]) (stopIteration bool)) (stopIteration bool) {
+	for _, value := range n.values {
+		if f(Interval[T, V]{Start: n.start, End: n.end, Value: value}) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *IntervalTree[T, V]) overlapping(n *intervalNode[T, V], a, b T, f func(interval Interval[T, V]) (stopIteration bool)) (stopIteration bool) {
+	if n == nil || t.compare(n.maxEnd, a) < 0 {
+		// 子树中所有区间都在a之前结束
+		return false
+	}
+
+	if t.overlapping(n.left, a, b, f) {
+		return true
+	}
+	if t.compare(n.start, b) > 0 {
+		// 本节点和右子树的区间都在b之后开始
+		return false
+	}
+	if t.compare(n.end, a) >= 0 && n.each(f) {
+		return true
+	}
+	return t.overlapping(n.right, a, b, f)
+}
+
+// RangeOverlapping 按顺序遍历和闭区间[a, b]重叠的区间。
+func (t *IntervalTree[T, V]) RangeOverlapping(a, b T, f func(interval Interval[T, V]) (stopIteration bool)) {
+	t.overlapping(t.root, a, b, f)
+}
+
+// Overlapping 按顺序返回和闭区间[a, b]重叠的区间。
+func (t *IntervalTree[T, V]) Overlapping(a, b T) []Interval[T, V] {
+	var intervals []Interval[T, V]
+	t.overlapping(t.root, a, b, func(interval Interval[T, V]) (stopIteration bool) {
+		intervals = append(intervals, interval)
+		return false
+	})
+	return intervals
+}
+
+// Containing 按顺序返回包含point的区间。
+func (t *IntervalTree[T, V]) Containing(point T) []Interval[T, V] {
+	return t.Overlapping(point, point)
+}
+
+func (t *IntervalTree[T, V]) walk(n *intervalNode[T, V], f func(interval Interval[T, V]) (stopIteration bool)) (stopIteration bool) {
+	if n == nil {
+		return false
+	}
+	return t.walk(n.left, f) || n.each(f) || t.walk(n.right, f)
+}
+
+// Walk 按Start、End的顺序遍历全部区间。相同的区间按写入的顺序。
+func (t *IntervalTree[T, V]) Walk(f func(interval Interval[T, V]) (stopIteration bool)) {
+	t.walk(t.root, f)
+}
+
+// Len 区间的个数。
+func (t *IntervalTree[T, V]) Len() int {
+	return t.size
+}
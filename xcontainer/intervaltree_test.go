@@ -0,0 +1,202 @@
+package xcontainer
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIntervalTree_Overlapping(t *testing.T) {
+	tree := NewIntervalTree[int, string]()
+	tree.Insert(15, 20, "a")
+	tree.Insert(10, 30, "b")
+	tree.Insert(17, 19, "c")
+	tree.Insert(5, 20, "d")
+	tree.Insert(12, 15, "e")
+	tree.Insert(30, 40, "f")
+	assert.Equal(t, 6, tree.Len())
+
+	var values []string
+	for _, interval := range tree.Overlapping(6, 7) {
+		values = append(values, interval.Value)
+	}
+	assert.Equal(t, []string{"d"}, values)
+
+	values = nil
+	for _, interval := range tree.Overlapping(19, 30) {
+		values = append(values, interval.Value)
+	}
+	assert.Equal(t, []string{"d", "b", "a", "c", "f"}, values)
+
+	values = nil
+	for _, interval := range tree.Containing(15) {
+		values = append(values, interval.Value)
+	}
+	assert.Equal(t, []string{"d", "b", "e", "a"}, values)
+
+	assert.Empty(t, tree.Overlapping(41, 50))
+	assert.Empty(t, tree.Containing(0))
+}
+
+func TestIntervalTree_InsertDelete(t *testing.T) {
+	tree := NewIntervalTree[int, int]()
+
+	tree.Insert(1, 2, 1)
+	tree.Insert(3, 4, 3)
+	assert.Equal(t, []int{1}, tree.Get(1, 2))
+	assert.Equal(t, 2, tree.Len())
+
+	assert.Equal(t, []int{1}, tree.Delete(1, 2))
+	assert.Nil(t, tree.Delete(1, 2))
+	assert.Nil(t, tree.Get(1, 2))
+	assert.Equal(t, 1, tree.Len())
+
+	assert.Panics(t, func() {
+		tree.Insert(2, 1, 0)
+	})
+}
+
+func TestIntervalTree_Duplicate(t *testing.T) {
+	tree := NewIntervalTree[int, string]()
+
+	// 同一时段的多个预约
+	tree.Insert(9, 10, "alice")
+	tree.Insert(9, 10, "bob")
+	tree.Insert(8, 12, "carol")
+	tree.Insert(9, 10, "dave")
+	assert.Equal(t, 4, tree.Len())
+	assert.Equal(t, []string{"alice", "bob", "dave"}, tree.Get(9, 10))
+
+	var values []string
+	for _, interval := range tree.Containing(9) {
+		values = append(values, interval.Value)
+	}
+	assert.Equal(t, []string{"carol", "alice", "bob", "dave"}, values)
+
+	// 取消其中一个
+	deleted := tree.DeleteFunc(9, 10, func(value string) bool {
+		return value == "bob"
+	})
+	assert.Equal(t, []string{"bob"}, deleted)
+	assert.Equal(t, []string{"alice", "dave"}, tree.Get(9, 10))
+	assert.Equal(t, 3, tree.Len())
+
+	assert.Equal(t, []string{"alice", "dave"}, tree.Delete(9, 10))
+	assert.Equal(t, 1, tree.Len())
+	assert.Len(t, tree.Overlapping(9, 10), 1)
+}
+
+func TestIntervalTree_Random(t *testing.T) {
+	tree := NewIntervalTree[int, int]()
+	intervals := make(map[[2]int][]int)
+
+	for i := 0; i < 2000; i++ {
+		start := rand.Intn(1000)
+		end := start + rand.Intn(50)
+		tree.Insert(start, end, i)
+		intervals[[2]int{start, end}] = append(intervals[[2]int{start, end}], i)
+	}
+	// 删除一部分
+	for key := range intervals {
+		if rand.Intn(2) == 0 {
+			assert.Equal(t, intervals[key], tree.Delete(key[0], key[1]))
+			delete(intervals, key)
+		}
+	}
+	var size int
+	for _, values := range intervals {
+		size += len(values)
+	}
+	assert.Equal(t, size, tree.Len())
+	assertIntervalTreeBalanced(t, tree, tree.root)
+
+	for i := 0; i < 100; i++ {
+		a := rand.Intn(1100)
+		b := a + rand.Intn(30)
+
+		var want [][2]int
+		for key, values := range intervals {
+			if key[0] <= b && key[1] >= a {
+				for range values {
+					want = append(want, key)
+				}
+			}
+		}
+		sort.Slice(want, func(i, j int) bool {
+			if want[i][0] != want[j][0] {
+				return want[i][0] < want[j][0]
+			}
+			return want[i][1] < want[j][1]
+		})
+
+		var got [][2]int
+		for _, interval := range tree.Overlapping(a, b) {
+			got = append(got, [2]int{interval.Start, interval.End})
+		}
+		assert.Equal(t, want, got, "[%d, %d]", a, b)
+	}
+}
+
+func TestIntervalTree_Walk(t *testing.T) {
+	tree := NewIntervalTree[int, int]()
+	for _, i := range rand.Perm(100) {
+		tree.Insert(i, i+10, i)
+	}
+
+	var values []int
+	tree.Walk(func(interval Interval[int, int]) (stopIteration bool) {
+		values = append(values, interval.Value)
+		return interval.Value >= 9
+	})
+	assert.Equal(t, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, values)
+}
+
+func TestIntervalTree_Time(t *testing.T) {
+	tree := NewIntervalTreeFunc[time.Time, string](func(a, b time.Time) int {
+		switch {
+		case a.Before(b):
+			return -1
+		case a.After(b):
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	base := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	tree.Insert(base, base.Add(time.Hour), "morning")
+	tree.Insert(base.Add(2*time.Hour), base.Add(3*time.Hour), "noon")
+
+	intervals := tree.Containing(base.Add(30 * time.Minute))
+	if assert.Len(t, intervals, 1) {
+		assert.Equal(t, "morning", intervals[0].Value)
+	}
+	assert.Len(t, tree.Overlapping(base.Add(time.Hour), base.Add(2*time.Hour)), 2)
+}
+
+func assertIntervalTreeBalanced(t *testing.T, tree *IntervalTree[int, int], n *intervalNode[int, int]) int {
+	if n == nil {
+		return 0
+	}
+	left := assertIntervalTreeBalanced(t, tree, n.left)
+	right := assertIntervalTreeBalanced(t, tree, n.right)
+	assert.LessOrEqual(t, left-right, 1)
+	assert.LessOrEqual(t, right-left, 1)
+
+	maxEnd := n.end
+	if n.left != nil && n.left.maxEnd > maxEnd {
+		maxEnd = n.left.maxEnd
+	}
+	if n.right != nil && n.right.maxEnd > maxEnd {
+		maxEnd = n.right.maxEnd
+	}
+	assert.Equal(t, maxEnd, n.maxEnd)
+
+	if left > right {
+		return left + 1
+	}
+	return right + 1
+}
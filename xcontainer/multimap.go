@@ -0,0 +1,167 @@
+package xcontainer
+
+import "container/list"
+
+type multiMapEntry[K comparable, V comparable] struct {
+	key   K
+	value V
+}
+
+// MultiMap 一个key对应多个值的容器。
+// 同一key的值保持插入顺序；全部kv对也保持插入顺序。
+// 非并发安全。
+type MultiMap[K comparable, V comparable] struct {
+	sequence *list.List
+	mapping  map[K][]*list.Element
+}
+
+// NewMultiMap 新建一个MultiMap。
+func NewMultiMap[K comparable, V comparable]() *MultiMap[K, V] {
+	return &MultiMap[K, V]{
+		sequence: list.New(),
+		mapping:  make(map[K][]*list.Element),
+	}
+}
+
+// Add 给key追加一个或多个值。
+func (m *MultiMap[K, V]) Add(key K, values ...V) {
+	for _, value := range values {
+		elem := m.sequence.PushBack(&multiMapEntry[K, V]{key: key, value: value})
+		m.mapping[key] = append(m.mapping[key], elem)
+	}
+}
+
+// Get 获取key的第一个值。
+func (m *MultiMap[K, V]) Get(key K) (value V, ok bool) {
+	elems := m.mapping[key]
+	if len(elems) == 0 {
+		return value, false
+	}
+	return elems[0].Value.(*multiMapEntry[K, V]).value, true
+}
+
+// GetAll 按插入顺序获取key的全部值。返回的切片可以修改。
+func (m *MultiMap[K, V]) GetAll(key K) []V {
+	elems := m.mapping[key]
+	if len(elems) == 0 {
+		return nil
+	}
+
+	values := make([]V, 0, len(elems))
+	for _, elem := range elems {
+		values = append(values, elem.Value.(*multiMapEntry[K, V]).value)
+	}
+	return values
+}
+
+// Has key是否存在。
+func (m *MultiMap[K, V]) Has(key K) bool {
+	_, ok := m.mapping[key]
+	return ok
+}
+
+// Remove 删除key下全部等于value的值。返回删除的个数。
+func (m *MultiMap[K, V]) Remove(key K, value V) int {
+	elems, ok := m.mapping[key]
+	if !ok {
+		return 0
+	}
+
+	var removed int
+	remains := elems[:0]
+	for _, elem := range elems {
+		entry := elem.Value.(*multiMapEntry[K, V])
+		if entry.value == value {
+			m.sequence.Remove(elem)
+			removed++
+		} else {
+			remains = append(remains, elem)
+		}
+	}
+	for i := len(remains); i < len(elems); i++ {
+		elems[i] = nil // 不再引用已删除的元素
+	}
+
+	if len(remains) == 0 {
+		delete(m.mapping, key)
+	} else {
+		m.mapping[key] = remains
+	}
+	return removed
+}
+
+// RemoveAll 删除key的全部值。按插入顺序返回被删除的值。
+func (m *MultiMap[K, V]) RemoveAll(key K) []V {
+	elems, ok := m.mapping[key]
+	if !ok {
+		return nil
+	}
+	delete(m.mapping, key)
+
+	values := make([]V, 0, len(elems))
+	for _, elem := range elems {
+		m.sequence.Remove(elem)
+		values = append(values, elem.Value.(*multiMapEntry[K, V]).value)
+	}
+	return values
+}
+
+// Len 全部kv对的个数。
+func (m *MultiMap[K, V]) Len() int {
+	return m.sequence.Len()
+}
+
+// KeyLen 不同key的个数。
+func (m *MultiMap[K, V]) KeyLen() int {
+	return len(m.mapping)
+}
+
+// Count key的值的个数。
+func (m *MultiMap[K, V]) Count(key K) int {
+	return len(m.mapping[key])
+}
+
+// Keys 按首次插入的顺序返回全部key。
+func (m *MultiMap[K, V]) Keys() []K {
+	keys := make([]K, 0, len(m.mapping))
+	seen := make(map[K]struct{}, len(m.mapping))
+	for elem := m.sequence.Front(); elem != nil; elem = elem.Next() {
+		entry := elem.Value.(*multiMapEntry[K, V])
+		if _, ok := seen[entry.key]; ok {
+			continue
+		}
+		seen[entry.key] = struct{}{}
+		keys = append(keys, entry.key)
+	}
+	return keys
+}
+
+// RangeKey 按插入顺序遍历key的全部值。
+func (m *MultiMap[K, V]) RangeKey(key K, f func(value V) (stopIteration bool)) {
+	for _, elem := range m.mapping[key] {
+		entry := elem.Value.(*multiMapEntry[K, V])
+		if f(entry.value) {
+			return
+		}
+	}
+}
+
+// Range 按插入顺序遍历全部kv对。
+func (m *MultiMap[K, V]) Range(f func(key K, value V) (stopIteration bool)) {
+	elem := m.sequence.Front()
+	for elem != nil {
+		entry := elem.Value.(*multiMapEntry[K, V])
+		stopIteration := f(entry.key, entry.value)
+		if stopIteration {
+			return
+		}
+
+		elem = elem.Next()
+	}
+}
+
+// Clear 清理全部数据。
+func (m *MultiMap[K, V]) Clear() {
+	m.mapping = make(map[K][]*list.Element)
+	m.sequence = list.New()
+}
@@ -0,0 +1,91 @@
+package xcontainer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMultiMap_Add(t *testing.T) {
+	m := NewMultiMap[string, int]()
+
+	m.Add("a", 1, 2)
+	m.Add("b", 3)
+	m.Add("a", 4)
+
+	assert.Equal(t, []int{1, 2, 4}, m.GetAll("a"))
+	assert.Equal(t, []int{3}, m.GetAll("b"))
+	assert.Nil(t, m.GetAll("c"))
+
+	v, ok := m.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+	_, ok = m.Get("c")
+	assert.False(t, ok)
+
+	assert.Equal(t, 4, m.Len())
+	assert.Equal(t, 2, m.KeyLen())
+	assert.Equal(t, 3, m.Count("a"))
+	assert.Equal(t, 0, m.Count("c"))
+	assert.True(t, m.Has("b"))
+	assert.False(t, m.Has("c"))
+	assert.Equal(t, []string{"a", "b"}, m.Keys())
+}
+
+func TestMultiMap_Remove(t *testing.T) {
+	m := NewMultiMap[string, int]()
+
+	m.Add("a", 1, 2, 1, 3)
+	m.Add("b", 1)
+
+	assert.Equal(t, 2, m.Remove("a", 1))
+	assert.Equal(t, []int{2, 3}, m.GetAll("a"))
+	assert.Equal(t, 0, m.Remove("a", 1))
+	assert.Equal(t, 0, m.Remove("c", 1))
+	assert.Equal(t, 3, m.Len())
+
+	assert.Equal(t, 1, m.Remove("b", 1))
+	assert.False(t, m.Has("b"))
+
+	assert.Equal(t, []int{2, 3}, m.RemoveAll("a"))
+	assert.Nil(t, m.RemoveAll("a"))
+	assert.Equal(t, 0, m.Len())
+	assert.Equal(t, 0, m.KeyLen())
+}
+
+func TestMultiMap_Range(t *testing.T) {
+	m := NewMultiMap[string, int]()
+
+	m.Add("a", 1)
+	m.Add("b", 2)
+	m.Add("a", 3)
+	m.Add("c", 4)
+
+	var keys []string
+	var values []int
+	m.Range(func(key string, value int) (stopIteration bool) {
+		keys = append(keys, key)
+		values = append(values, value)
+		return false
+	})
+	assert.Equal(t, []string{"a", "b", "a", "c"}, keys)
+	assert.Equal(t, []int{1, 2, 3, 4}, values)
+
+	keys = nil
+	m.Range(func(key string, value int) (stopIteration bool) {
+		keys = append(keys, key)
+		return value >= 2
+	})
+	assert.Equal(t, []string{"a", "b"}, keys)
+
+	values = nil
+	m.RangeKey("a", func(value int) (stopIteration bool) {
+		values = append(values, value)
+		return false
+	})
+	assert.Equal(t, []int{1, 3}, values)
+
+	m.Clear()
+	assert.Equal(t, 0, m.Len())
+	assert.Nil(t, m.GetAll("a"))
+}
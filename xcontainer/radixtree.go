@@ -0,0 +1,262 @@
+package xcontainer
+
+import (
+	"sort"
+	"strings"
+)
+
+type radixNode[V any] struct {
+	// prefix 从父节点到本节点的边上的字符串。
+	prefix string
+
+	// leaf 本节点是否存有值。
+	leaf  bool
+	value V
+
+	// children 子节点。按prefix的首字节排序，首字节各不相同。
+	children []*radixNode[V]
+}
+
+// child 根据首字节查找子节点。
+func (n *radixNode[V]) child(label byte) (int, *radixNode[V]) {
+	idx := sort.Search(len(n.children), func(i int) bool {
+		return n.children[i].prefix[0] >= label
+	})
+	if idx < len(n.children) && n.children[idx].prefix[0] == label {
+		return idx, n.children[idx]
+	}
+	return idx, nil
+}
+
+// addChild 添加子节点，保持排序。
+func (n *radixNode[V]) addChild(child *radixNode[V]) {
+	idx, _ := n.child(child.prefix[0])
+	n.children = append(n.children, nil)
+	copy(n.children[idx+1:], n.children[idx:])
+	n.children[idx] = child
+}
+
+// removeChild 删除子节点。
+func (n *radixNode[V]) removeChild(label byte) {
+	idx, child := n.child(label)
+	if child == nil {
+		return
+	}
+	copy(n.children[idx:], n.children[idx+1:])
+	n.children[len(n.children)-1] = nil
+	n.children = n.children[:len(n.children)-1]
+}
+
+// mergeChild 本节点没有值，并且只有一个子节点时，合并子节点到本节点。
+func (n *radixNode[V]) mergeChild() {
+	child := n.children[0]
+	n.prefix = n.prefix + child.prefix
+	n.leaf = child.leaf
+	n.value = child.value
+	n.children = child.children
+}
+
+// walk 先序遍历。结果按key的字典序。
+func (n *radixNode[V]) walk(key string, f func(key string, value V) (stopIteration bool)) (stopIteration bool) {
+	if n.leaf && f(key, n.value) {
+		return true
+	}
+	for _, child := range n.children {
+		if child.walk(key+child.prefix, f) {
+			return true
+		}
+	}
+	return false
+}
+
+// RadixTree 字符串key的基数树。支持前缀查找。
+// 非并发安全。
+type RadixTree[V any] struct {
+	root radixNode[V]
+	size int
+}
+
+// NewRadixTree 新建一个RadixTree。
+func NewRadixTree[V any]() *RadixTree[V] {
+	return &RadixTree[V]{}
+}
+
+// commonPrefixLen 两个字符串的公共前缀的长度。
+func commonPrefixLen(a, b string) int {
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+	for i := 0; i < n; i++ {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	return n
+}
+
+// Insert 写入kv对。如果key已经存在，返回旧值，updated为true。
+func (t *RadixTree[V]) Insert(key string, value V) (old V, updated bool) {
+	n := &t.root
+	search := key
+	for {
+		if len(search) == 0 {
+			if n.leaf {
+				old = n.value
+				n.value = value
+				return old, true
+			}
+			n.leaf = true
+			n.value = value
+			t.size++
+			return old, false
+		}
+
+		idx, child := n.child(search[0])
+		if child == nil {
+			n.addChild(&radixNode[V]{prefix: search, leaf: true, value: value})
+			t.size++
+			return old, false
+		}
+
+		common := commonPrefixLen(search, child.prefix)
+		if common == len(child.prefix) {
+			search = search[common:]
+			n = child
+			continue
+		}
+
+		// 分裂子节点
+		split := &radixNode[V]{prefix: search[:common]}
+		n.children[idx] = split
+		child.prefix = child.prefix[common:]
+		split.children = []*radixNode[V]{child}
+
+		search = search[common:]
+		if len(search) == 0 {
+			split.leaf = true
+			split.value = value
+		} else {
+			split.addChild(&radixNode[V]{prefix: search, leaf: true, value: value})
+		}
+		t.size++
+		return old, false
+	}
+}
+
+// Get 根据key获取值。
+func (t *RadixTree[V]) Get(key string) (value V, ok bool) {
+	n := &t.root
+	search := key
+	for {
+		if len(search) == 0 {
+			if n.leaf {
+				return n.value, true
+			}
+			return value, false
+		}
+
+		_, child := n.child(search[0])
+		if child == nil || !strings.HasPrefix(search, child.prefix) {
+			return value, false
+		}
+		search = search[len(child.prefix):]
+		n = child
+	}
+}
+
+// Delete 删除key。返回被删除的值。
+func (t *RadixTree[V]) Delete(key string) (value V, ok bool) {
+	var parent *radixNode[V]
+	n := &t.root
+	search := key
+	for len(search) > 0 {
+		_, child := n.child(search[0])
+		if child == nil || !strings.HasPrefix(search, child.prefix) {
+			return value, false
+		}
+		search = search[len(child.prefix):]
+		parent = n
+		n = child
+	}
+	if !n.leaf {
+		return value, false
+	}
+
+	value = n.value
+	var zero V
+	n.leaf = false
+	n.value = zero
+	t.size--
+
+	// 压缩节点
+	if parent == nil {
+		return value, true
+	}
+	switch len(n.children) {
+	case 0:
+		parent.removeChild(n.prefix[0])
+		if parent != &t.root && !parent.leaf && len(parent.children) == 1 {
+			parent.mergeChild()
+		}
+	case 1:
+		n.mergeChild()
+	}
+	return value, true
+}
+
+// LongestPrefix 查找是s的前缀的最长的key。
+func (t *RadixTree[V]) LongestPrefix(s string) (key string, value V, ok bool) {
+	n := &t.root
+	search := s
+	for {
+		if n.leaf {
+			key = s[:len(s)-len(search)]
+			value = n.value
+			ok = true
+		}
+		if len(search) == 0 {
+			return key, value, ok
+		}
+
+		_, child := n.child(search[0])
+		if child == nil || !strings.HasPrefix(search, child.prefix) {
+			return key, value, ok
+		}
+		search = search[len(child.prefix):]
+		n = child
+	}
+}
+
+// WalkPrefix 按key的字典序，遍历以prefix为前缀的kv对。
+func (t *RadixTree[V]) WalkPrefix(prefix string, f func(key string, value V) (stopIteration bool)) {
+	n := &t.root
+	search := prefix
+	for len(search) > 0 {
+		_, child := n.child(search[0])
+		if child == nil {
+			return
+		}
+		if strings.HasPrefix(search, child.prefix) {
+			search = search[len(child.prefix):]
+			n = child
+			continue
+		}
+		if strings.HasPrefix(child.prefix, search) {
+			// prefix在边的中间结束
+			child.walk(prefix+child.prefix[len(search):], f)
+		}
+		return
+	}
+	n.walk(prefix, f)
+}
+
+// Walk 按key的字典序，遍历全部kv对。
+func (t *RadixTree[V]) Walk(f func(key string, value V) (stopIteration bool)) {
+	t.root.walk("", f)
+}
+
+// Len kv对的个数。
+func (t *RadixTree[V]) Len() int {
+	return t.size
+}
@@ -0,0 +1,77 @@
+package xcontainer
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func newBenchmarkRoutes() []string {
+	var routes []string
+	for i := 0; i < 100; i++ {
+		for j := 0; j < 10; j++ {
+			routes = append(routes, fmt.Sprintf("/api/v%d/resource_%d", j, i))
+		}
+	}
+	return routes
+}
+
+func BenchmarkRadixTree_LongestPrefix(b *testing.B) {
+	routes := newBenchmarkRoutes()
+	tree := NewRadixTree[int]()
+	for i, route := range routes {
+		tree.Insert(route, i)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		route := routes[i%len(routes)]
+		tree.LongestPrefix(route + "/items/123")
+	}
+}
+
+func BenchmarkMapScan_LongestPrefix(b *testing.B) {
+	routes := newBenchmarkRoutes()
+	m := make(map[string]int, len(routes))
+	for i, route := range routes {
+		m[route] = i
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s := routes[i%len(routes)] + "/items/123"
+
+		var longest string
+		for key := range m {
+			if len(key) > len(longest) && strings.HasPrefix(s, key) {
+				longest = key
+			}
+		}
+	}
+}
+
+func BenchmarkRadixTree_Get(b *testing.B) {
+	routes := newBenchmarkRoutes()
+	tree := NewRadixTree[int]()
+	for i, route := range routes {
+		tree.Insert(route, i)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tree.Get(routes[i%len(routes)])
+	}
+}
+
+func BenchmarkMap_Get(b *testing.B) {
+	routes := newBenchmarkRoutes()
+	m := make(map[string]int, len(routes))
+	for i, route := range routes {
+		m[route] = i
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = m[routes[i%len(routes)]]
+	}
+}
@@ -0,0 +1,157 @@
+package xcontainer
+
+import (
+	"math/rand"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRadixTree_Insert(t *testing.T) {
+	tree := NewRadixTree[int]()
+
+	keys := []string{"", "a", "ab", "abc", "abd", "b", "ba", "romane", "romanus", "romulus", "rubens", "ruber", "rubicon", "rubicundus"}
+	for i, key := range keys {
+		_, updated := tree.Insert(key, i)
+		assert.False(t, updated, key)
+	}
+	assert.Equal(t, len(keys), tree.Len())
+
+	for i, key := range keys {
+		v, ok := tree.Get(key)
+		if assert.True(t, ok, key) {
+			assert.Equal(t, i, v)
+		}
+	}
+	for _, key := range []string{"abcd", "r", "roman", "rub", "c"} {
+		_, ok := tree.Get(key)
+		assert.False(t, ok, key)
+	}
+
+	old, updated := tree.Insert("roman", 100)
+	assert.False(t, updated)
+	assert.Equal(t, 0, old)
+	old, updated = tree.Insert("roman", 101)
+	assert.True(t, updated)
+	assert.Equal(t, 100, old)
+	v, _ := tree.Get("roman")
+	assert.Equal(t, 101, v)
+}
+
+func TestRadixTree_Delete(t *testing.T) {
+	tree := NewRadixTree[int]()
+
+	keys := rand.Perm(1000)
+	for _, key := range keys {
+		tree.Insert(strconv.Itoa(key), key)
+	}
+	for i, key := range keys {
+		if i%2 == 0 {
+			v, ok := tree.Delete(strconv.Itoa(key))
+			if assert.True(t, ok) {
+				assert.Equal(t, key, v)
+			}
+		}
+	}
+	assert.Equal(t, len(keys)/2, tree.Len())
+
+	for i, key := range keys {
+		v, ok := tree.Get(strconv.Itoa(key))
+		if i%2 == 0 {
+			assert.False(t, ok)
+		} else if assert.True(t, ok) {
+			assert.Equal(t, key, v)
+		}
+	}
+
+	_, ok := tree.Delete("not_exist")
+	assert.False(t, ok)
+
+	// 全部删除后，不留下多余的节点
+	for i, key := range keys {
+		if i%2 != 0 {
+			tree.Delete(strconv.Itoa(key))
+		}
+	}
+	assert.Equal(t, 0, tree.Len())
+	assert.Empty(t, tree.root.children)
+}
+
+func TestRadixTree_LongestPrefix(t *testing.T) {
+	tree := NewRadixTree[string]()
+	tree.Insert("/", "root")
+	tree.Insert("/api", "api")
+	tree.Insert("/api/v1", "v1")
+	tree.Insert("/api/v2", "v2")
+
+	tests := []struct {
+		s       string
+		wantKey string
+		wantOk  bool
+	}{
+		{s: "", wantOk: false},
+		{s: "/", wantKey: "/", wantOk: true},
+		{s: "/ap", wantKey: "/", wantOk: true},
+		{s: "/api/", wantKey: "/api", wantOk: true},
+		{s: "/api/v1/users", wantKey: "/api/v1", wantOk: true},
+		{s: "/api/v3", wantKey: "/api", wantOk: true},
+		{s: "api", wantOk: false},
+	}
+	for _, tt := range tests {
+		key, value, ok := tree.LongestPrefix(tt.s)
+		assert.Equal(t, tt.wantOk, ok, tt.s)
+		if tt.wantOk {
+			assert.Equal(t, tt.wantKey, key, tt.s)
+			v, _ := tree.Get(key)
+			assert.Equal(t, v, value, tt.s)
+		}
+	}
+}
+
+func TestRadixTree_Walk(t *testing.T) {
+	tree := NewRadixTree[int]()
+
+	keys := []string{"romane", "romanus", "romulus", "rubens", "ruber", "rubicon", "rubicundus", "a"}
+	for i, key := range keys {
+		tree.Insert(key, i)
+	}
+	sorted := append([]string{}, keys...)
+	sort.Strings(sorted)
+
+	var got []string
+	tree.Walk(func(key string, value int) (stopIteration bool) {
+		got = append(got, key)
+		return false
+	})
+	assert.Equal(t, sorted, got)
+
+	got = nil
+	tree.WalkPrefix("rub", func(key string, value int) (stopIteration bool) {
+		got = append(got, key)
+		return false
+	})
+	assert.Equal(t, []string{"rubens", "ruber", "rubicon", "rubicundus"}, got)
+
+	got = nil
+	tree.WalkPrefix("rom", func(key string, value int) (stopIteration bool) {
+		got = append(got, key)
+		return false
+	})
+	assert.Equal(t, []string{"romane", "romanus", "romulus"}, got)
+
+	got = nil
+	tree.WalkPrefix("rubi", func(key string, value int) (stopIteration bool) {
+		got = append(got, key)
+		return true
+	})
+	assert.Equal(t, []string{"rubicon"}, got)
+
+	got = nil
+	tree.WalkPrefix("x", func(key string, value int) (stopIteration bool) {
+		got = append(got, key)
+		return false
+	})
+	assert.Empty(t, got)
+}
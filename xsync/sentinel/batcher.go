@@ -0,0 +1,177 @@
+package sentinel
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Batcher 批量加载器。
+// 收集不同调用者的单个key的请求，等待maxWait时长，或者凑满maxBatch个key后，合并为一次批量执行。
+// 通过Group合并相同key的请求，执行过的key不重复执行。
+type Batcher[K comparable, V any] struct {
+	group Group[K, V]
+	f     GroupMDoFunc[K, V]
+
+	maxWait  time.Duration
+	maxBatch int
+
+	mu      sync.Mutex
+	pending *batch[K, V]
+}
+
+// batch 一次批量执行。
+type batch[K comparable, V any] struct {
+	ctx  context.Context
+	keys []K
+
+	// indexes key在keys中的下标。
+	indexes map[K]int
+
+	timer *time.Timer
+
+	// done 执行完成后关闭。
+	done    chan struct{}
+	results []V
+	errs    []error
+	err     error
+}
+
+// NewBatcher 新建批量加载器。
+// maxWait为收集请求的最长时间；maxBatch为一次批量执行的最多key个数，maxBatch<=0时不限制。
+// f使用的context保留批次中第一个请求的ctx的Value，但不随它取消；设置了WithDetachedContext的最长执行时间时，限制批量执行的时长。
+// opts同Group的选项。总是使用独立执行模式，每个调用者按自己的ctx等待，调用者的ctx结束不影响key的结果。
+func NewBatcher[K comparable, V any](f GroupMDoFunc[K, V], maxWait time.Duration, maxBatch int, opts ...Option) *Batcher[K, V] {
+	o := newOptions(opts...)
+	o.detached = true
+	b := &Batcher[K, V]{
+		f:        f,
+		maxWait:  maxWait,
+		maxBatch: maxBatch,
+	}
+	b.group.setOptions(o)
+	return b
+}
+
+// Load 加载key对应的数据。key删除（或者按选项释放）前，不重复执行key相同的逻辑。
+// 返回的结果是共享的，不可修改；除非设置了WithCloner或者WithCloneMethod。
+// 如果批量执行的函数panic，批次内的全部调用者都得到*PanicError。
+func (b *Batcher[K, V]) Load(ctx context.Context, key K) (V, error) {
+	return b.group.Do(ctx, key, func(ctx context.Context) (V, error) {
+		return b.load(ctx, key)
+	})
+}
+
+// LoadChan 同Load，但不阻塞，返回接收结果的通道。通道只接收一个结果。
+func (b *Batcher[K, V]) LoadChan(ctx context.Context, key K) <-chan Result[V] {
+	return b.group.DoChan(ctx, key, func(ctx context.Context) (V, error) {
+		return b.load(ctx, key)
+	})
+}
+
+// load 将key加入批次，等待批量执行的结果。
+// ctx是Group独立执行的context，不随调用者的ctx取消；调用者的等待由Group处理。
+// 全部调用者都放弃等待（key被删除），或者超过WithDetachedContext的最长执行时间时，ctx结束，不再等待。
+func (b *Batcher[K, V]) load(ctx context.Context, key K) (result V, err error) {
+	bt, index := b.add(ctx, key)
+
+	select {
+	case <-bt.done:
+	case <-ctx.Done():
+		return result, ctx.Err()
+	}
+
+	if bt.err != nil {
+		return result, bt.err
+	}
+	if index < len(bt.errs) && bt.errs[index] != nil {
+		return result, bt.errs[index]
+	}
+	return bt.results[index], nil
+}
+
+// add 将key加入等待中的批次，返回批次和key的下标。
+func (b *Batcher[K, V]) add(ctx context.Context, key K) (*batch[K, V], int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	bt := b.pending
+	if bt == nil {
+		bt = &batch[K, V]{
+			ctx:     detachedContext{parent: ctx},
+			indexes: make(map[K]int),
+			done:    make(chan struct{}),
+		}
+		b.pending = bt
+		bt.timer = time.AfterFunc(b.maxWait, func() {
+			b.flush(bt)
+		})
+	}
+
+	index, ok := bt.indexes[key]
+	if !ok {
+		index = len(bt.keys)
+		bt.keys = append(bt.keys, key)
+		bt.indexes[key] = index
+	}
+
+	if b.maxBatch > 0 && len(bt.keys) >= b.maxBatch {
+		// 凑满了，立即执行
+		bt.timer.Stop()
+		b.pending = nil
+		go b.execute(bt)
+	}
+	return bt, index
+}
+
+// flush 等待时间到了，执行批次。
+func (b *Batcher[K, V]) flush(bt *batch[K, V]) {
+	b.mu.Lock()
+	if b.pending != bt {
+		// 已经凑满执行了
+		b.mu.Unlock()
+		return
+	}
+	b.pending = nil
+	b.mu.Unlock()
+
+	b.execute(bt)
+}
+
+// execute 批量执行，通知批次内的全部调用者。
+func (b *Batcher[K, V]) execute(bt *batch[K, V]) {
+	defer close(bt.done)
+	defer func() {
+		if r := recover(); r != nil {
+			bt.err = newPanicError(r)
+		}
+	}()
+
+	ctx := bt.ctx
+	if b.group.opts.maxExecution > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, b.group.opts.maxExecution)
+		defer cancel()
+	}
+
+	bt.results, bt.errs, bt.err = b.f(ctx, bt.keys)
+	if bt.err == nil && !enoughResults(bt.results, bt.errs, len(bt.keys)) {
+		bt.err = errNotEnoughResults
+	}
+}
+
+// Stats 返回统计。每个key计为一次执行。
+func (b *Batcher[K, V]) Stats() Stats {
+	return b.group.Stats()
+}
+
+// Forget 删除key对应的哨兵。
+// 正在执行的逻辑继续执行，已经在等待的调用者仍得到它的结果；之后的调用者重新加载该key。
+func (b *Batcher[K, V]) Forget(key K) {
+	b.group.Forget(key)
+}
+
+// Delete 删除key对应的哨兵。下次需要重新加载该key。
+func (b *Batcher[K, V]) Delete(keys ...K) {
+	b.group.Delete(keys...)
+}
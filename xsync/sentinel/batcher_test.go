@@ -0,0 +1,193 @@
+package sentinel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatcher_Load(t *testing.T) {
+	var mu sync.Mutex
+	var batches [][]int
+	fMDo := func(ctx context.Context, keys []int) ([]string, []error, error) {
+		mu.Lock()
+		batches = append(batches, append([]int(nil), keys...))
+		mu.Unlock()
+
+		results := make([]string, len(keys))
+		errs := make([]error, len(keys))
+		for i, key := range keys {
+			if key < 0 {
+				errs[i] = fmt.Errorf("invalid key: %d", key)
+				continue
+			}
+			results[i] = fmt.Sprintf("echo: %d", key)
+		}
+		return results, errs, nil
+	}
+	b := NewBatcher(fMDo, time.Millisecond*50, 0)
+
+	// 并发加载，合并为一次批量执行
+	keys := []int{1, 2, 3, 2, 1, -1}
+	var wg sync.WaitGroup
+	for _, key := range keys {
+		wg.Add(1)
+		go func(key int) {
+			defer wg.Done()
+
+			result, err := b.Load(context.TODO(), key)
+			if key < 0 {
+				assert.EqualError(t, err, "invalid key: -1")
+				return
+			}
+			if assert.Nil(t, err) {
+				assert.Equal(t, fmt.Sprintf("echo: %d", key), result)
+			}
+		}(key)
+	}
+	wg.Wait()
+
+	if assert.Len(t, batches, 1) {
+		sort.Ints(batches[0])
+		assert.Equal(t, []int{-1, 1, 2, 3}, batches[0])
+	}
+
+	// 已经加载过，不重复执行
+	result, err := b.Load(context.TODO(), 3)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "echo: 3", result)
+	}
+	assert.Len(t, batches, 1)
+
+	// 删除后重新加载
+	b.Delete(3)
+	result, err = b.Load(context.TODO(), 3)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "echo: 3", result)
+	}
+	if assert.Len(t, batches, 2) {
+		assert.Equal(t, []int{3}, batches[1])
+	}
+}
+
+func TestBatcher_MaxBatch(t *testing.T) {
+	var mu sync.Mutex
+	var batchSizes []int
+	fMDo := func(ctx context.Context, keys []int) ([]int, []error, error) {
+		mu.Lock()
+		batchSizes = append(batchSizes, len(keys))
+		mu.Unlock()
+
+		return keys, nil, nil
+	}
+	// 等待时间足够长，只有凑满才执行
+	b := NewBatcher(fMDo, time.Hour, 3)
+
+	var chs []<-chan Result[int]
+	for key := 0; key < 6; key++ {
+		chs = append(chs, b.LoadChan(context.TODO(), key))
+	}
+	for key, ch := range chs {
+		result := <-ch
+		if assert.Nil(t, result.Err) {
+			assert.Equal(t, key, result.Val)
+		}
+	}
+	assert.Equal(t, []int{3, 3}, batchSizes)
+}
+
+func TestBatcher_Error(t *testing.T) {
+	// 整体失败
+	b := NewBatcher(func(ctx context.Context, keys []int) ([]int, []error, error) {
+		return nil, nil, errors.New("test")
+	}, time.Millisecond, 0, WithSingleflight())
+	_, err := b.Load(context.TODO(), 1)
+	assert.EqualError(t, err, "test")
+
+	// 结果不够
+	b = NewBatcher(func(ctx context.Context, keys []int) ([]int, []error, error) {
+		return nil, nil, nil
+	}, time.Millisecond, 0, WithSingleflight())
+	_, err = b.Load(context.TODO(), 1)
+	assert.Equal(t, errNotEnoughResults, err)
+
+	// panic
+	b = NewBatcher(func(ctx context.Context, keys []int) ([]int, []error, error) {
+		panic("wow")
+	}, time.Millisecond, 0, WithSingleflight())
+	_, err = b.Load(context.TODO(), 1)
+	var panicErr *PanicError
+	if assert.ErrorAs(t, err, &panicErr) {
+		assert.Equal(t, "wow", panicErr.Value)
+	}
+}
+
+func TestBatcher_ContextCanceled(t *testing.T) {
+	start := make(chan struct{})
+	b := NewBatcher(func(ctx context.Context, keys []int) ([]int, []error, error) {
+		<-start
+		return keys, nil, nil
+	}, time.Millisecond, 0, WithSingleflight())
+	defer close(start)
+
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Millisecond*20)
+	defer cancel()
+	_, err := b.Load(ctx, 1)
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestBatcher_ContextCanceledNotRetained(t *testing.T) {
+	var count int32
+	b := NewBatcher(func(ctx context.Context, keys []int) ([]int, []error, error) {
+		atomic.AddInt32(&count, 1)
+		return keys, nil, nil
+	}, time.Millisecond*50, 0)
+
+	// 第一个调用者等待超时
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Millisecond*10)
+	defer cancel()
+	_, err := b.Load(ctx, 1)
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	// 超时的错误不作为key的结果
+	result, err := b.Load(context.Background(), 1)
+	if assert.Nil(t, err) {
+		assert.Equal(t, 1, result)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&count))
+}
+
+func TestBatcher_MaxExecution(t *testing.T) {
+	b := NewBatcher(func(ctx context.Context, keys []int) ([]int, []error, error) {
+		_, ok := ctx.Deadline()
+		assert.True(t, ok)
+
+		<-ctx.Done() // 后端没有响应
+		return nil, nil, ctx.Err()
+	}, time.Millisecond, 0, WithDetachedContext(time.Millisecond*50), WithSingleflight())
+
+	start := time.Now()
+	_, err := b.Load(context.Background(), 1)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestBatcher_MaxExecutionIgnored(t *testing.T) {
+	hang := make(chan struct{})
+	defer close(hang)
+	b := NewBatcher(func(ctx context.Context, keys []int) ([]int, []error, error) {
+		<-hang // 不检查ctx
+		return keys, nil, nil
+	}, time.Millisecond, 0, WithDetachedContext(time.Millisecond*50), WithSingleflight())
+
+	// 批次没有完成，也不再等待
+	_, err := b.Load(context.Background(), 1)
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
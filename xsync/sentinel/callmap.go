@@ -0,0 +1,243 @@
+package sentinel
+
+import (
+	"math"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// callShardCount 分片个数。
+const callShardCount = 32
+
+// callShard 一个分片。每个分片有自己的锁。
+type callShard[K comparable, V any] struct {
+	mu sync.Mutex
+	m  map[K]*call[V]
+}
+
+// loadOrStoreLocked 同callMap.loadOrStore。调用者需要持有锁。
+func (s *callShard[K, V]) loadOrStoreLocked(key K, c *call[V]) (actual *call[V], loaded bool) {
+	if actual, loaded = s.m[key]; loaded {
+		// 已经完成的执行，即使调用者都已经放弃等待，结果仍然可以共享
+		if actual.flight == nil || actual.flight.join(1) || actual.isDone() {
+			atomic.AddInt32(&actual.dups, 1)
+			return actual, true
+		}
+	}
+	if s.m == nil {
+		s.m = make(map[K]*call[V])
+	}
+	s.m[key] = c
+	return c, false
+}
+
+// callMap 按key的哈希分片的哨兵表。减少写多时的锁竞争。
+// 零值可用。
+type callMap[K comparable, V any] struct {
+	shards [callShardCount]callShard[K, V]
+}
+
+func (cm *callMap[K, V]) shard(key K) *callShard[K, V] {
+	return &cm.shards[hashKey(key)%callShardCount]
+}
+
+// loadOrStore 如果key已经有哨兵，返回已有的哨兵；否则存入c。
+// 如果已有的执行还没完成，并且全部调用者都已经放弃等待，替换为c。
+func (cm *callMap[K, V]) loadOrStore(key K, c *call[V]) (actual *call[V], loaded bool) {
+	s := cm.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.loadOrStoreLocked(key, c)
+}
+
+// loadOrStoreBatch 对每个key执行loadOrStore。每个分片只加锁一次。
+// 需要存入时，调用newCall创建哨兵。
+// 同一分片内，按keys的顺序处理。keys不应该重复，否则重复的key计为等待自己。
+func (cm *callMap[K, V]) loadOrStoreBatch(keys []K, newCall func() *call[V]) (actuals []*call[V], loadeds []bool) {
+	actuals = make([]*call[V], len(keys))
+	loadeds = make([]bool, len(keys))
+
+	var shardIndexes [callShardCount][]int
+	for index, key := range keys {
+		i := hashKey(key) % callShardCount
+		shardIndexes[i] = append(shardIndexes[i], index)
+	}
+
+	var c *call[V]
+	for i, indexes := range shardIndexes {
+		if len(indexes) == 0 {
+			continue
+		}
+
+		s := &cm.shards[i]
+		s.mu.Lock()
+		for _, index := range indexes {
+			if c == nil { // 如果已经使用，创建一个新的
+				c = newCall()
+			}
+			actuals[index], loadeds[index] = s.loadOrStoreLocked(keys[index], c)
+			if !loadeds[index] {
+				c = nil // 已经使用，下次需要重新创建
+			}
+		}
+		s.mu.Unlock()
+	}
+	return actuals, loadeds
+}
+
+// remove 如果key的哨兵还是c，删除它。
+func (cm *callMap[K, V]) remove(key K, c *call[V]) {
+	s := cm.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.m[key] == c {
+		delete(s.m, key)
+	}
+}
+
+// delete 删除keys的哨兵。
+func (cm *callMap[K, V]) delete(keys ...K) {
+	for _, key := range keys {
+		s := cm.shard(key)
+		s.mu.Lock()
+		delete(s.m, key)
+		s.mu.Unlock()
+	}
+}
+
+// len 哨兵的个数。
+func (cm *callMap[K, V]) len() int {
+	var n int
+	for i := range cm.shards {
+		s := &cm.shards[i]
+		s.mu.Lock()
+		n += len(s.m)
+		s.mu.Unlock()
+	}
+	return n
+}
+
+const (
+	fnvOffset64 = 14695981039346656037
+	fnvPrime64  = 1099511628211
+)
+
+// hashString FNV-1a哈希。
+func hashString(s string) uint64 {
+	h := uint64(fnvOffset64)
+	for i := 0; i < len(s); i++ {
+		h ^= uint64(s[i])
+		h *= fnvPrime64
+	}
+	return h
+}
+
+// hashUint64 splitmix64的终结函数。
+func hashUint64(x uint64) uint64 {
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
+
+// hashFloat 计算浮点数的哈希。-0和+0相等。
+func hashFloat(f float64) uint64 {
+	if f == 0 {
+		return hashUint64(0)
+	}
+	return hashUint64(math.Float64bits(f))
+}
+
+// combineHash 合并哈希。
+func combineHash(h, x uint64) uint64 {
+	return (h ^ x) * fnvPrime64
+}
+
+// hashKey 计算key的哈希，用于选择分片。
+// 字符串和数字直接计算，其它类型按hashValue计算。
+func hashKey[K comparable](key K) uint64 {
+	switch k := any(key).(type) {
+	case string:
+		return hashString(k)
+	case int:
+		return hashUint64(uint64(k))
+	case int8:
+		return hashUint64(uint64(k))
+	case int16:
+		return hashUint64(uint64(k))
+	case int32:
+		return hashUint64(uint64(k))
+	case int64:
+		return hashUint64(uint64(k))
+	case uint:
+		return hashUint64(uint64(k))
+	case uint8:
+		return hashUint64(uint64(k))
+	case uint16:
+		return hashUint64(uint64(k))
+	case uint32:
+		return hashUint64(uint64(k))
+	case uint64:
+		return hashUint64(k)
+	case uintptr:
+		return hashUint64(uint64(k))
+	case float32:
+		return hashFloat(float64(k))
+	case float64:
+		return hashFloat(k)
+	case bool:
+		if k {
+			return 1
+		}
+		return 0
+	default:
+		return hashValue(reflect.ValueOf(key))
+	}
+}
+
+// hashValue 通过反射计算哈希。相等的值哈希相等。
+// 结构体和数组逐个字段（元素）计算；指针按地址计算，不能按指向的内容计算。
+func hashValue(value reflect.Value) uint64 {
+	switch value.Kind() {
+	case reflect.String:
+		return hashString(value.String())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return hashUint64(uint64(value.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return hashUint64(value.Uint())
+	case reflect.Float32, reflect.Float64:
+		return hashFloat(value.Float())
+	case reflect.Complex64, reflect.Complex128:
+		c := value.Complex()
+		return combineHash(hashFloat(real(c)), hashFloat(imag(c)))
+	case reflect.Bool:
+		if value.Bool() {
+			return 1
+		}
+		return 0
+	case reflect.Pointer, reflect.Chan, reflect.UnsafePointer:
+		return hashUint64(uint64(value.Pointer()))
+	case reflect.Interface:
+		if value.IsNil() {
+			return 0
+		}
+		return hashValue(value.Elem())
+	case reflect.Array:
+		h := uint64(fnvOffset64)
+		for i := 0; i < value.Len(); i++ {
+			h = combineHash(h, hashValue(value.Index(i)))
+		}
+		return h
+	case reflect.Struct:
+		h := uint64(fnvOffset64)
+		for i := 0; i < value.NumField(); i++ {
+			h = combineHash(h, hashValue(value.Field(i)))
+		}
+		return h
+	default:
+		// 其它类型不能作为key
+		return 0
+	}
+}
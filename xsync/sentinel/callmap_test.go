@@ -0,0 +1,94 @@
+package sentinel
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashKey(t *testing.T) {
+	assert.Equal(t, hashKey("hello"), hashKey("hel"+"lo"))
+	assert.NotEqual(t, hashKey("hello"), hashKey("world"))
+	assert.Equal(t, hashKey(123), hashKey(100+23))
+	assert.Equal(t, hashKey(0.0), hashKey(math.Copysign(0, -1)))
+
+	type compositeKey struct {
+		Tenant string
+		ID     int
+	}
+	assert.Equal(t, hashKey(compositeKey{"a", 1}), hashKey(compositeKey{"a", 1}))
+	assert.NotEqual(t, hashKey(compositeKey{"a", 1}), hashKey(compositeKey{"a", 2}))
+
+	// 字段中的-0和+0相等
+	type floatKey struct {
+		F float64
+		C complex64
+	}
+	assert.Equal(t, hashKey(floatKey{F: 0}), hashKey(floatKey{F: math.Copysign(0, -1)}))
+	assert.Equal(t, hashKey(floatKey{C: 0}), hashKey(floatKey{C: complex(float32(math.Copysign(0, -1)), 0)}))
+
+	// 未导出字段和数组
+	type nestedKey struct {
+		name string
+		ids  [2]int
+	}
+	assert.Equal(t, hashKey(nestedKey{"a", [2]int{1, 2}}), hashKey(nestedKey{"a", [2]int{1, 2}}))
+	assert.NotEqual(t, hashKey(nestedKey{"a", [2]int{1, 2}}), hashKey(nestedKey{"a", [2]int{2, 1}}))
+
+	// 指针按地址计算
+	p := &compositeKey{"a", 1}
+	h := hashKey(p)
+	p.ID = 2
+	assert.Equal(t, h, hashKey(p))
+}
+
+func TestCallMap_LoadOrStoreBatch(t *testing.T) {
+	var cm callMap[int, int]
+
+	existing := &call[int]{TypedSentinel: NewTypedSentinel[int]()}
+	actual, loaded := cm.loadOrStore(2, existing)
+	assert.False(t, loaded)
+	assert.Equal(t, existing, actual)
+
+	var created []*call[int]
+	newCall := func() *call[int] {
+		c := &call[int]{TypedSentinel: NewTypedSentinel[int]()}
+		created = append(created, c)
+		return c
+	}
+	keys := []int{1, 2, 3, 1, 100}
+	actuals, loadeds := cm.loadOrStoreBatch(keys, newCall)
+	assert.Equal(t, []bool{false, true, false, true, false}, loadeds)
+	assert.Equal(t, existing, actuals[1])
+	assert.Equal(t, actuals[0], actuals[3]) // 重复的key，等待第一个
+	assert.Equal(t, 4, cm.len())
+
+	// 多创建的最多一个
+	assert.LessOrEqual(t, len(created), 4)
+
+	cm.remove(1, existing) // 不是key的哨兵，不删除
+	assert.Equal(t, 4, cm.len())
+	cm.remove(1, actuals[0])
+	cm.delete(2, 3, 100)
+	assert.Equal(t, 0, cm.len())
+}
+
+func TestGroup_FloatFieldKey(t *testing.T) {
+	type floatKey struct {
+		F float64
+	}
+	g := NewGroup[floatKey, int]()
+
+	var count int
+	f := func(ctx context.Context) (int, error) {
+		count++
+		return count, nil
+	}
+	_, err := g.Do(context.TODO(), floatKey{0}, f)
+	assert.Nil(t, err)
+	_, err = g.Do(context.TODO(), floatKey{math.Copysign(0, -1)}, f)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, count)
+}
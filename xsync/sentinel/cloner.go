@@ -0,0 +1,56 @@
+package sentinel
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// WithCloner 每个调用者都得到clone复制的结果，可以修改。包括执行的调用者。
+// V是结果的类型。SentinelGroup的结果的类型是destPtr指向的类型。
+// 结果的类型不是V时，调用者得到ErrClonerMismatch。
+// Group应该使用NewGroupWithCloner，在编译时检查类型。
+// 默认不复制，结果是共享的。
+func WithCloner[V any](clone func(V) V) Option {
+	return func(o *options) {
+		o.typedCloner = clone
+		o.cloner = func(value interface{}) (interface{}, error) {
+			if value == nil {
+				return nil, nil
+			}
+			v, ok := value.(V)
+			if !ok {
+				return nil, fmt.Errorf("%w: cloner of %T, result of %T", ErrClonerMismatch, clone, value)
+			}
+			return clone(v), nil
+		}
+	}
+}
+
+// WithCloneMethod 每个调用者都得到结果的Clone方法复制的结果，可以修改。包括执行的调用者。
+// Clone方法没有参数，返回一个和结果相同类型的值。例如：func (s Items) Clone() Items。
+// 结果没有Clone方法时，不复制。
+// 默认不复制，结果是共享的。
+func WithCloneMethod() Option {
+	return func(o *options) {
+		o.typedCloner = nil
+		o.cloner = cloneByMethod
+	}
+}
+
+// cloneByMethod 调用value的Clone方法复制value。
+func cloneByMethod(value interface{}) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	v := reflect.ValueOf(value)
+	method := v.MethodByName("Clone")
+	if !method.IsValid() {
+		return value, nil
+	}
+	methodType := method.Type()
+	if methodType.NumIn() != 0 || methodType.NumOut() != 1 || !methodType.Out(0).AssignableTo(v.Type()) {
+		return value, nil
+	}
+	return method.Call(nil)[0].Convert(v.Type()).Interface(), nil
+}
@@ -0,0 +1,146 @@
+package sentinel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type cloneableItems []string
+
+func (items cloneableItems) Clone() cloneableItems {
+	return append(cloneableItems(nil), items...)
+}
+
+func TestGroup_WithCloner(t *testing.T) {
+	g := NewGroup[string, []string](WithCloner(func(items []string) []string {
+		return append([]string(nil), items...)
+	}))
+	fDo := func(ctx context.Context) ([]string, error) {
+		return []string{"a", "b"}, nil
+	}
+
+	// 执行的调用者也得到复制的结果
+	result1, err := g.Do(context.TODO(), "key", fDo)
+	if assert.Nil(t, err) {
+		assert.Equal(t, []string{"a", "b"}, result1)
+	}
+	result1[0] = "changed"
+
+	result2, err := g.Do(context.TODO(), "key", fDo)
+	if assert.Nil(t, err) {
+		assert.Equal(t, []string{"a", "b"}, result2)
+	}
+	result2[1] = "changed"
+
+	results, _, err := g.MDo(context.TODO(), []string{"key"}, nil)
+	if assert.Nil(t, err) {
+		assert.Equal(t, [][]string{{"a", "b"}}, results)
+	}
+}
+
+func TestGroup_NewGroupWithCloner(t *testing.T) {
+	g := NewGroupWithCloner[string](func(items []string) []string {
+		return append([]string(nil), items...)
+	})
+	shared := []string{"a", "b"}
+	fDo := func(ctx context.Context) ([]string, error) {
+		return shared, nil
+	}
+
+	result, err := g.Do(context.TODO(), "key", fDo)
+	if assert.Nil(t, err) {
+		assert.Equal(t, []string{"a", "b"}, result)
+	}
+	result[0] = "changed"
+	assert.Equal(t, "a", shared[0])
+}
+
+func TestGroup_WithClonerMismatch(t *testing.T) {
+	// 函数的类型和V不同，得到错误，不panic
+	g := NewGroup[string, []int](WithCloner(func(items []string) []string {
+		return append([]string(nil), items...)
+	}))
+	_, err := g.Do(context.TODO(), "key", func(ctx context.Context) ([]int, error) {
+		return []int{1}, nil
+	})
+	assert.ErrorIs(t, err, ErrClonerMismatch)
+
+	results, errs, err := g.MDo(context.TODO(), []string{"key"}, nil)
+	if assert.Nil(t, err) && assert.Len(t, errs, 1) {
+		assert.ErrorIs(t, errs[0], ErrClonerMismatch)
+		assert.Nil(t, results[0])
+	}
+}
+
+func TestGroup_WithCloneMethod(t *testing.T) {
+	g := NewGroup[string, cloneableItems](WithCloneMethod())
+	fDo := func(ctx context.Context) (cloneableItems, error) {
+		return cloneableItems{"a", "b"}, nil
+	}
+
+	result1, err := g.Do(context.TODO(), "key", fDo)
+	if assert.Nil(t, err) {
+		assert.Equal(t, cloneableItems{"a", "b"}, result1)
+	}
+	result1[0] = "changed"
+
+	result2, err := g.Do(context.TODO(), "key", fDo)
+	if assert.Nil(t, err) {
+		assert.Equal(t, cloneableItems{"a", "b"}, result2)
+	}
+
+	// 没有Clone方法，不复制
+	g2 := NewGroup[string, []string](WithCloneMethod())
+	shared := []string{"a"}
+	result3, err := g2.Do(context.TODO(), "key", func(ctx context.Context) ([]string, error) {
+		return shared, nil
+	})
+	if assert.Nil(t, err) {
+		result3[0] = "changed"
+		assert.Equal(t, "changed", shared[0])
+	}
+}
+
+func TestSentinelGroup_WithCloner(t *testing.T) {
+	sg := NewSentinelGroup(WithCloner(func(items []string) []string {
+		return append([]string(nil), items...)
+	}))
+	fDo := func(ctx context.Context, destPtr, args interface{}) error {
+		*(destPtr.(*[]string)) = []string{"a", "b"}
+		return nil
+	}
+
+	var resp1 []string
+	err := sg.Do(context.TODO(), &resp1, "key", nil, fDo)
+	if assert.Nil(t, err) {
+		assert.Equal(t, []string{"a", "b"}, resp1)
+	}
+	resp1[0] = "changed"
+
+	var resp2 []string
+	err = sg.Do(context.TODO(), &resp2, "key", nil, fDo)
+	if assert.Nil(t, err) {
+		assert.Equal(t, []string{"a", "b"}, resp2)
+	}
+
+	var resps [][]string
+	errs, err := sg.MDo(context.TODO(), &resps, []string{"key"}, []interface{}{nil}, nil)
+	if assert.Nil(t, err) && assert.Nil(t, errs) {
+		assert.Equal(t, [][]string{{"a", "b"}}, resps)
+	}
+}
+
+func TestSentinelGroup_WithClonerMismatch(t *testing.T) {
+	sg := NewSentinelGroup(WithCloner(func(items []string) []string {
+		return append([]string(nil), items...)
+	}))
+
+	var resp []int
+	err := sg.Do(context.TODO(), &resp, "key", nil, func(ctx context.Context, destPtr, args interface{}) error {
+		*(destPtr.(*[]int)) = []int{1}
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrClonerMismatch)
+}
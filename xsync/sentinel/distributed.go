@@ -0,0 +1,403 @@
+package sentinel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// Backend 跨进程协调的后端。提供锁和结果的存储。
+// 哨兵组先在进程内合并请求，再通过后端在多个进程间合并请求：
+// 只有获得锁的进程执行，并保存结果；其它进程等待，读取保存的结果。
+type Backend interface {
+	// TryLock 尝试获得key的锁，不阻塞。锁在ttl后自动失效。
+	// 获得锁时，返回解锁使用的token。
+	TryLock(ctx context.Context, key string, ttl time.Duration) (token string, acquired bool, err error)
+
+	// Unlock 释放key的锁。token不匹配时（例如锁已经失效，被其它进程获得），不释放。
+	Unlock(ctx context.Context, key string, token string) error
+
+	// Load 读取key的结果。
+	Load(ctx context.Context, key string) (data []byte, found bool, err error)
+
+	// Store 保存key的结果。结果在ttl后失效。
+	Store(ctx context.Context, key string, data []byte, ttl time.Duration) error
+
+	// Delete 删除key的结果。key没有结果时，不返回错误。
+	Delete(ctx context.Context, key string) error
+}
+
+// Codec 结果的编解码。
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// JSONCodec JSON编解码。
+type JSONCodec struct{}
+
+// Marshal 编码。
+func (JSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal 解码。
+func (JSONCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// DistributedConfig 跨进程协调的配置。
+type DistributedConfig struct {
+	// Backend 后端。必须设置。
+	Backend Backend
+
+	// Codec 结果的编解码。默认JSONCodec。
+	Codec Codec
+
+	// KeyPrefix 后端的key的前缀。用于区分不同的哨兵组。
+	// 后端的key为前缀加上KeyFunc的结果。
+	KeyPrefix string
+
+	// KeyFunc 将key转为后端的key（不含前缀），不同的key必须得到不同的结果。
+	// 默认字符串key不变，其它类型的key按%#v格式化（字符串字段带引号）。
+	// key是接口类型（如SentinelGroupOf[any]、Group[any, V]）时，按%T:%#v格式化，带上key的动态类型。
+	// key包含指针时，格式化的结果是地址，不能跨进程使用，需要设置。
+	KeyFunc func(key interface{}) string
+
+	// LockTTL 锁的有效时长，应该大于执行的时长。默认10秒。
+	LockTTL time.Duration
+
+	// ResultTTL 保存的结果的有效时长。默认1分钟。
+	ResultTTL time.Duration
+
+	// PollInterval 其它进程在执行时，检查结果的间隔。默认50毫秒。
+	PollInterval time.Duration
+
+	// DeleteTimeout Delete和Forget删除后端保存的结果的最长时间。默认1秒。
+	DeleteTimeout time.Duration
+}
+
+// WithDistributed 通过后端在多个进程间合并请求。
+// 只保存执行成功的结果；执行失败时，等待的其它进程获得锁后重新执行。
+// 批量处理时，每个key分别加锁和保存结果。MDoStream不通过后端协调。
+// Delete和Forget同时删除后端保存的结果。
+func WithDistributed(config DistributedConfig) Option {
+	d := &distributor{
+		backend:       config.Backend,
+		codec:         config.Codec,
+		keyPrefix:     config.KeyPrefix,
+		keyFunc:       config.KeyFunc,
+		lockTTL:       config.LockTTL,
+		resultTTL:     config.ResultTTL,
+		pollInterval:  config.PollInterval,
+		deleteTimeout: config.DeleteTimeout,
+	}
+	if d.codec == nil {
+		d.codec = JSONCodec{}
+	}
+	if d.lockTTL <= 0 {
+		d.lockTTL = time.Second * 10
+	}
+	if d.resultTTL <= 0 {
+		d.resultTTL = time.Minute
+	}
+	if d.pollInterval <= 0 {
+		d.pollInterval = time.Millisecond * 50
+	}
+	if d.deleteTimeout <= 0 {
+		d.deleteTimeout = time.Second
+	}
+	return func(o *options) {
+		o.distributed = d
+	}
+}
+
+// distributor 通过后端协调多个进程。
+type distributor struct {
+	backend   Backend
+	codec     Codec
+	keyPrefix string
+	keyFunc   func(key interface{}) string
+
+	lockTTL       time.Duration
+	resultTTL     time.Duration
+	pollInterval  time.Duration
+	deleteTimeout time.Duration
+}
+
+// backendKeyOf 后端使用的key。
+// K是接口类型时（使用方的模块为go1.20及以上版本才可以），同一个哨兵组的key可能有不同的动态类型，格式化的结果需要带上类型。
+func backendKeyOf[K comparable](d *distributor, key K) string {
+	return d.backendKey(key, reflect.TypeOf((*K)(nil)).Elem().Kind() == reflect.Interface)
+}
+
+// backendKey 后端使用的key。typed为true时，按%T:%#v格式化，字符串"1"和整数1不会混淆。
+func (d *distributor) backendKey(key interface{}, typed bool) string {
+	if d.keyFunc != nil {
+		return d.keyPrefix + d.keyFunc(key)
+	}
+	if typed {
+		return d.keyPrefix + fmt.Sprintf("%T:%#v", key, key)
+	}
+	if s, ok := key.(string); ok {
+		return d.keyPrefix + s
+	}
+	return d.keyPrefix + fmt.Sprintf("%#v", key)
+}
+
+// load 读取并解码key的结果。
+func (d *distributor) load(ctx context.Context, key string, decode func(data []byte) (interface{}, error)) (value interface{}, found bool, err error) {
+	data, found, err := d.backend.Load(ctx, key)
+	if err != nil || !found {
+		return nil, false, err
+	}
+	value, err = decode(data)
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+// store 编码并保存key的结果。保存失败不影响本次执行的结果，忽略错误。
+func (d *distributor) store(ctx context.Context, key string, value interface{}) {
+	data, err := d.codec.Marshal(value)
+	if err != nil {
+		return
+	}
+	_ = d.backend.Store(ctx, key, data, d.resultTTL)
+}
+
+// delete 删除key的结果，最长等待DeleteTimeout。删除失败时，结果在ResultTTL后失效，忽略错误。
+func (d *distributor) delete(key string) {
+	ctx, cancel := context.WithTimeout(context.Background(), d.deleteTimeout)
+	defer cancel()
+	_ = d.backend.Delete(ctx, key)
+}
+
+// unlock 释放锁。调用者的ctx可能已经结束，不使用它。
+func (d *distributor) unlock(ctx context.Context, key, token string) {
+	_ = d.backend.Unlock(detachedContext{parent: ctx}, key, token)
+}
+
+// wait 等待下次检查。ctx结束时返回false。
+func (d *distributor) wait(ctx context.Context) bool {
+	timer := time.NewTimer(d.pollInterval)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// do 读取保存的结果；没有结果时，获得锁的进程执行f并保存结果，其它进程等待。
+func (d *distributor) do(ctx context.Context, key string, f func(ctx context.Context) (interface{}, error), decode func(data []byte) (interface{}, error)) (interface{}, error) {
+	for {
+		value, found, err := d.load(ctx, key, decode)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			return value, nil
+		}
+
+		token, acquired, err := d.backend.TryLock(ctx, key, d.lockTTL)
+		if err != nil {
+			return nil, err
+		}
+		if acquired {
+			return d.produce(ctx, key, token, f, decode)
+		}
+
+		// 其它进程在执行
+		if !d.wait(ctx) {
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// produce 获得锁后执行f，保存结果。
+func (d *distributor) produce(ctx context.Context, key, token string, f func(ctx context.Context) (interface{}, error), decode func(data []byte) (interface{}, error)) (interface{}, error) {
+	defer d.unlock(ctx, key, token)
+
+	// 获得锁前，其它进程可能刚保存了结果
+	value, found, err := d.load(ctx, key, decode)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		return value, nil
+	}
+
+	value, err = f(ctx)
+	if err != nil {
+		return nil, err
+	}
+	d.store(ctx, key, value)
+	return value, nil
+}
+
+// mdo 批量处理时，每个key分别读取结果和加锁。
+// run执行获得锁的key，参数是key在keys中的下标，返回的结果顺序同参数。
+// 返回的[]interface{}和[]error的顺序和长度等于keys的顺序和长度。
+func (d *distributor) mdo(ctx context.Context, keys []string, run func(ctx context.Context, indexes []int) ([]interface{}, []error, error), decode func(data []byte) (interface{}, error)) ([]interface{}, []error, error) {
+	values := make([]interface{}, len(keys))
+	errs := make([]error, len(keys))
+
+	pending := make([]int, len(keys)) // 还没有结果的key的下标
+	for i := range keys {
+		pending[i] = i
+	}
+	for len(pending) > 0 {
+		var runIndexes []int
+		var tokens []string
+		var waiting []int
+		for _, index := range pending {
+			value, found, err := d.load(ctx, keys[index], decode)
+			if err != nil {
+				errs[index] = err
+				continue
+			}
+			if found {
+				values[index] = value
+				continue
+			}
+
+			token, acquired, err := d.backend.TryLock(ctx, keys[index], d.lockTTL)
+			switch {
+			case err != nil:
+				errs[index] = err
+			case acquired:
+				runIndexes = append(runIndexes, index)
+				tokens = append(tokens, token)
+			default:
+				waiting = append(waiting, index)
+			}
+		}
+
+		if len(runIndexes) > 0 {
+			err := d.produceBatch(ctx, keys, runIndexes, tokens, values, errs, run, decode)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+
+		if len(waiting) > 0 && !d.wait(ctx) {
+			for _, index := range waiting {
+				errs[index] = ctx.Err()
+			}
+			break
+		}
+		pending = waiting
+	}
+	return values, errs, nil
+}
+
+// produceBatch 获得锁后批量执行，保存结果。
+func (d *distributor) produceBatch(ctx context.Context, keys []string, indexes []int, tokens []string, values []interface{}, errs []error, run func(ctx context.Context, indexes []int) ([]interface{}, []error, error), decode func(data []byte) (interface{}, error)) error {
+	defer func() {
+		for i, index := range indexes {
+			d.unlock(ctx, keys[index], tokens[i])
+		}
+	}()
+
+	// 获得锁前，其它进程可能刚保存了结果
+	var runIndexes []int
+	for _, index := range indexes {
+		value, found, err := d.load(ctx, keys[index], decode)
+		switch {
+		case err != nil:
+			errs[index] = err
+		case found:
+			values[index] = value
+		default:
+			runIndexes = append(runIndexes, index)
+		}
+	}
+	if len(runIndexes) == 0 {
+		return nil
+	}
+
+	runValues, runErrs, err := run(ctx, runIndexes)
+	if err == nil && !enoughResults(runValues, runErrs, len(runIndexes)) {
+		return errNotEnoughResults
+	}
+	for i, index := range runIndexes {
+		elemErr := err
+		if elemErr == nil && len(runErrs) > i { // 允许省去后面的nil
+			elemErr = runErrs[i]
+		}
+		if elemErr != nil {
+			errs[index] = elemErr
+			continue
+		}
+		values[index] = runValues[i]
+		d.store(ctx, keys[index], runValues[i])
+	}
+	return nil
+}
+
+// decodeAs 返回解码为V类型的函数。
+func decodeAs[V any](codec Codec) func(data []byte) (interface{}, error) {
+	return func(data []byte) (interface{}, error) {
+		var value V
+		err := codec.Unmarshal(data, &value)
+		return value, err
+	}
+}
+
+// distributedDo 通过后端协调执行f。d为nil时，直接返回f。
+func distributedDo[K comparable, V any](d *distributor, key K, f GroupDoFunc[V], decode func(data []byte) (interface{}, error)) GroupDoFunc[V] {
+	if d == nil {
+		return f
+	}
+	return func(ctx context.Context) (V, error) {
+		value, err := d.do(ctx, backendKeyOf(d, key), func(ctx context.Context) (interface{}, error) {
+			result, err := f(ctx)
+			return result, err
+		}, decode)
+		result, _ := value.(V)
+		return result, err
+	}
+}
+
+// distributedMDo 通过后端协调批量执行f。d为nil时，直接返回f。
+func distributedMDo[K comparable, V any](d *distributor, f GroupMDoFunc[K, V], decode func(data []byte) (interface{}, error)) GroupMDoFunc[K, V] {
+	if d == nil {
+		return f
+	}
+	return func(ctx context.Context, keys []K) ([]V, []error, error) {
+		backendKeys := make([]string, len(keys))
+		for i, key := range keys {
+			backendKeys[i] = backendKeyOf(d, key)
+		}
+
+		values, errs, err := d.mdo(ctx, backendKeys, func(ctx context.Context, indexes []int) ([]interface{}, []error, error) {
+			runKeys := make([]K, len(indexes))
+			for i, index := range indexes {
+				runKeys[i] = keys[index]
+			}
+			runValues, runErrs, err := f(ctx, runKeys)
+			if err != nil {
+				return nil, nil, err
+			}
+			values := make([]interface{}, len(runValues))
+			for i, value := range runValues {
+				values[i] = value
+			}
+			return values, runErrs, nil
+		}, decode)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		results := make([]V, len(keys))
+		for i, value := range values {
+			results[i], _ = value.(V)
+		}
+		return results, errs, nil
+	}
+}
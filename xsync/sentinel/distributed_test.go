@@ -0,0 +1,355 @@
+package sentinel
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testBackend(t *testing.T, backend Backend) {
+	ctx := context.TODO()
+
+	// 锁
+	token, acquired, err := backend.TryLock(ctx, "key", time.Millisecond*100)
+	if assert.Nil(t, err) {
+		assert.True(t, acquired)
+	}
+	_, acquired, err = backend.TryLock(ctx, "key", time.Millisecond*100)
+	if assert.Nil(t, err) {
+		assert.False(t, acquired)
+	}
+
+	// token不匹配，不释放
+	assert.Nil(t, backend.Unlock(ctx, "key", "other"))
+	_, acquired, _ = backend.TryLock(ctx, "key", time.Millisecond*100)
+	assert.False(t, acquired)
+
+	assert.Nil(t, backend.Unlock(ctx, "key", token))
+	_, acquired, _ = backend.TryLock(ctx, "key", time.Millisecond*100)
+	assert.True(t, acquired)
+
+	// 锁失效
+	time.Sleep(time.Millisecond * 150)
+	_, acquired, _ = backend.TryLock(ctx, "key", time.Millisecond*100)
+	assert.True(t, acquired)
+
+	// 结果
+	_, found, err := backend.Load(ctx, "key")
+	if assert.Nil(t, err) {
+		assert.False(t, found)
+	}
+	assert.Nil(t, backend.Store(ctx, "key", []byte("hello"), time.Millisecond*100))
+	data, found, err := backend.Load(ctx, "key")
+	if assert.Nil(t, err) && assert.True(t, found) {
+		assert.Equal(t, "hello", string(data))
+	}
+
+	// 结果失效
+	time.Sleep(time.Millisecond * 150)
+	_, found, err = backend.Load(ctx, "key")
+	if assert.Nil(t, err) {
+		assert.False(t, found)
+	}
+	// 删除结果
+	assert.Nil(t, backend.Store(ctx, "key", []byte("hello"), time.Minute))
+	assert.Nil(t, backend.Delete(ctx, "key"))
+	_, found, _ = backend.Load(ctx, "key")
+	assert.False(t, found)
+	assert.Nil(t, backend.Delete(ctx, "key"))
+}
+
+func TestMemoryBackend(t *testing.T) {
+	testBackend(t, NewMemoryBackend())
+}
+
+func TestGroup_Distributed(t *testing.T) {
+	backend := NewMemoryBackend()
+	config := DistributedConfig{
+		Backend:      backend,
+		KeyPrefix:    "test:",
+		PollInterval: time.Millisecond,
+	}
+
+	// 模拟多个进程
+	var groups []*Group[int, string]
+	for i := 0; i < 4; i++ {
+		groups = append(groups, NewGroup[int, string](WithDistributed(config)))
+	}
+
+	var count int32
+	fDo := func(ctx context.Context) (string, error) {
+		atomic.AddInt32(&count, 1)
+		time.Sleep(time.Millisecond * 10)
+		return "ok", nil
+	}
+
+	var wg sync.WaitGroup
+	for _, g := range groups {
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(g *Group[int, string]) {
+				defer wg.Done()
+
+				result, err := g.Do(context.TODO(), 1, fDo)
+				if assert.Nil(t, err) {
+					assert.Equal(t, "ok", result)
+				}
+			}(g)
+		}
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), count)
+
+	data, found, _ := backend.Load(context.TODO(), "test:1")
+	if assert.True(t, found) {
+		assert.Equal(t, `"ok"`, string(data))
+	}
+
+	// 批量
+	var mdoKeys [][]int
+	var mu sync.Mutex
+	fMDo := func(ctx context.Context, keys []int) ([]string, []error, error) {
+		mu.Lock()
+		mdoKeys = append(mdoKeys, append([]int(nil), keys...))
+		mu.Unlock()
+
+		var results []string
+		for _, key := range keys {
+			results = append(results, fmt.Sprintf("echo: %d", key))
+		}
+		return results, nil, nil
+	}
+	results, errs, err := groups[0].MDo(context.TODO(), []int{1, 2}, fMDo)
+	if assert.Nil(t, err) && assert.Nil(t, errs) {
+		assert.Equal(t, []string{"ok", "echo: 2"}, results)
+	}
+	// 其它进程读取保存的结果
+	results, errs, err = groups[1].MDo(context.TODO(), []int{2, 3}, fMDo)
+	if assert.Nil(t, err) && assert.Nil(t, errs) {
+		assert.Equal(t, []string{"echo: 2", "echo: 3"}, results)
+	}
+	assert.Equal(t, [][]int{{2}, {3}}, mdoKeys)
+}
+
+func TestGroup_DistributedWaitOtherProcess(t *testing.T) {
+	backend := NewMemoryBackend()
+	config := DistributedConfig{Backend: backend, PollInterval: time.Millisecond}
+	g := NewGroup[string, int](WithDistributed(config))
+
+	// 其它进程持有锁
+	token, acquired, _ := backend.TryLock(context.TODO(), "key", time.Second)
+	assert.True(t, acquired)
+
+	// 等待超时
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Millisecond*20)
+	defer cancel()
+	_, err := g.Do(ctx, "key", func(ctx context.Context) (int, error) {
+		return 1, nil
+	})
+	assert.Equal(t, context.DeadlineExceeded, err)
+	g.Delete("key")
+
+	// 其它进程保存结果
+	go func() {
+		time.Sleep(time.Millisecond * 10)
+		backend.Store(context.TODO(), "key", []byte("2"), time.Minute)
+		backend.Unlock(context.TODO(), "key", token)
+	}()
+	result, err := g.Do(context.TODO(), "key", func(ctx context.Context) (int, error) {
+		return 1, nil
+	})
+	if assert.Nil(t, err) {
+		assert.Equal(t, 2, result)
+	}
+}
+
+func TestGroup_DistributedDelete(t *testing.T) {
+	backend := NewMemoryBackend()
+	g := NewGroup[string, int](WithDistributed(DistributedConfig{Backend: backend}))
+
+	var count int
+	f := func(ctx context.Context) (int, error) {
+		count++
+		return count, nil
+	}
+
+	result, _ := g.Do(context.TODO(), "key", f)
+	assert.Equal(t, 1, result)
+
+	// 删除后，不读取后端保存的结果，重新执行
+	g.Delete("key")
+	_, found, _ := backend.Load(context.TODO(), "key")
+	assert.False(t, found)
+	result, _ = g.Do(context.TODO(), "key", f)
+	assert.Equal(t, 2, result)
+
+	g.Forget("key")
+	result, _ = g.Do(context.TODO(), "key", f)
+	assert.Equal(t, 3, result)
+}
+
+func TestGroup_DistributedStructKey(t *testing.T) {
+	type Key struct {
+		A, B string
+	}
+
+	config := DistributedConfig{Backend: NewMemoryBackend()}
+	g := NewGroup[Key, string](WithDistributed(config))
+
+	// 格式化后不能混淆
+	for _, key := range []Key{{"x y", "z"}, {"x", "y z"}} {
+		key := key
+		result, err := g.Do(context.TODO(), key, func(ctx context.Context) (string, error) {
+			return key.A + "|" + key.B, nil
+		})
+		if assert.Nil(t, err) {
+			assert.Equal(t, key.A+"|"+key.B, result)
+		}
+	}
+
+	// 自定义KeyFunc
+	backend := NewMemoryBackend()
+	config = DistributedConfig{Backend: backend, KeyFunc: func(key interface{}) string {
+		return key.(Key).A + "/" + key.(Key).B
+	}}
+	g = NewGroup[Key, string](WithDistributed(config))
+	_, err := g.Do(context.TODO(), Key{"x", "y"}, func(ctx context.Context) (string, error) {
+		return "xy", nil
+	})
+	assert.Nil(t, err)
+	data, found, _ := backend.Load(context.TODO(), "x/y")
+	if assert.True(t, found) {
+		assert.Equal(t, `"xy"`, string(data))
+	}
+}
+
+func TestDistributor_BackendKey(t *testing.T) {
+	d := &distributor{keyPrefix: "test:"}
+
+	assert.Equal(t, "test:1", d.backendKey("1", false))
+	assert.Equal(t, "test:1", d.backendKey(1, false))
+
+	// key是接口类型时，带上类型，字符串"1"和整数1不能混淆
+	assert.Equal(t, `test:string:"1"`, d.backendKey("1", true))
+	assert.Equal(t, "test:int:1", d.backendKey(1, true))
+	assert.Equal(t, "test:int64:1", d.backendKey(int64(1), true))
+
+	assert.Equal(t, "test:1", backendKeyOf(d, "1"))
+	assert.Equal(t, "test:1", backendKeyOf(d, 1))
+}
+
+func TestSentinelGroup_Distributed(t *testing.T) {
+	type Response struct {
+		Echo string
+	}
+
+	config := DistributedConfig{Backend: NewMemoryBackend(), PollInterval: time.Millisecond}
+	sg1 := NewSentinelGroup(WithDistributed(config))
+	sg2 := NewSentinelGroup(WithDistributed(config))
+
+	var count int
+	fDo := func(ctx context.Context, destPtr, args interface{}) error {
+		count++
+		*(destPtr.(*Response)) = Response{Echo: args.(string)}
+		return nil
+	}
+
+	var resp1 Response
+	err := sg1.Do(context.TODO(), &resp1, "key", "hello", fDo)
+	if assert.Nil(t, err) {
+		assert.Equal(t, Response{Echo: "hello"}, resp1)
+	}
+
+	// 其它进程解码保存的结果
+	var resp2 Response
+	err = sg2.Do(context.TODO(), &resp2, "key", "hello", fDo)
+	if assert.Nil(t, err) {
+		assert.Equal(t, Response{Echo: "hello"}, resp2)
+	}
+	assert.Equal(t, 1, count)
+
+	var resps []Response
+	errs, err := sg2.MDo(context.TODO(), &resps, []string{"key", "other"}, []string{"hello", "world"}, func(ctx context.Context, destSlicePtr, argsSlice interface{}) ([]error, error) {
+		for _, args := range argsSlice.([]string) {
+			count++
+			*(destSlicePtr.(*[]Response)) = append(*(destSlicePtr.(*[]Response)), Response{Echo: args})
+		}
+		return nil, nil
+	})
+	if assert.Nil(t, err) && assert.Nil(t, errs) {
+		assert.Equal(t, []Response{{Echo: "hello"}, {Echo: "world"}}, resps)
+	}
+	assert.Equal(t, 2, count)
+	// MDoMap也通过后端协调
+	resps = nil
+	var mapKeys []string
+	errs, err = sg1.MDoMap(context.TODO(), &resps, []string{"other", "third"}, []string{"world", "again"}, func(ctx context.Context, destMapPtr interface{}, keys []string, argsSlice interface{}) (map[string]error, error) {
+		mapKeys = append(mapKeys, keys...)
+		for idx, key := range keys {
+			count++
+			(*(destMapPtr.(*map[string]Response)))[key] = Response{Echo: argsSlice.([]string)[idx]}
+		}
+		return nil, nil
+	})
+	if assert.Nil(t, err) {
+		assert.Nil(t, errs)
+		assert.Equal(t, []Response{{Echo: "world"}, {Echo: "again"}}, resps)
+	}
+	assert.Equal(t, []string{"third"}, mapKeys)
+	assert.Equal(t, 3, count)
+}
+
+func TestGroup_DistributedWaitOrDo(t *testing.T) {
+	config := DistributedConfig{Backend: NewMemoryBackend(), PollInterval: time.Millisecond}
+	g := NewGroup[string, string](WithDistributed(config), WithWaitOrDo(time.Millisecond*20))
+
+	finish := make(chan struct{})
+	started := make(chan struct{})
+	leaderCh := g.DoChan(context.TODO(), "key", func(ctx context.Context) (string, error) {
+		close(started)
+		<-finish
+		return "leader", nil
+	})
+	<-started
+
+	// 自己执行不等待本进程执行者持有的后端锁
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Second)
+	defer cancel()
+	result, err := g.Do(ctx, "key", func(ctx context.Context) (string, error) {
+		return "hedged", nil
+	})
+	if assert.Nil(t, err) {
+		assert.Equal(t, "hedged", result)
+	}
+
+	close(finish)
+	leaderResult := <-leaderCh
+	if assert.Nil(t, leaderResult.Err) {
+		assert.Equal(t, "leader", leaderResult.Val)
+	}
+}
+
+// slowDeleteBackend 删除时没有响应的后端。
+type slowDeleteBackend struct {
+	*MemoryBackend
+}
+
+func (b slowDeleteBackend) Delete(ctx context.Context, key string) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestGroup_DistributedDeleteTimeout(t *testing.T) {
+	backend := slowDeleteBackend{MemoryBackend: NewMemoryBackend()}
+	g := NewGroup[string, int](WithDistributed(DistributedConfig{Backend: backend, DeleteTimeout: time.Millisecond * 10}))
+
+	start := time.Now()
+	g.Delete("key")
+	g.Forget("key")
+	assert.Less(t, time.Since(start), time.Second)
+}
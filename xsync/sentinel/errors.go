@@ -0,0 +1,68 @@
+package sentinel
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+)
+
+var (
+	// ErrProducerPanicked 生产者panic。等待者得到的错误是*PanicError，可以用errors.Is判断。
+	ErrProducerPanicked = errors.New("producer panicked")
+
+	// ErrAbandoned 生产者没有提交结果就关闭了哨兵。
+	ErrAbandoned = errors.New("sentinel abandoned")
+
+	// ErrAlreadyDone 哨兵已经Done或者Close，不能再次Done。
+	ErrAlreadyDone = errors.New("sentinel already done")
+
+	// ErrNotReady 生产者还没有提交结果。
+	ErrNotReady = errors.New("sentinel not ready")
+
+	// ErrNotFound 批量处理返回的map中没有key。等待者得到的错误是*NotFoundError，可以用errors.Is判断。
+	ErrNotFound = errors.New("not found")
+
+	// ErrClonerMismatch WithCloner的函数的类型和结果的类型不同。
+	ErrClonerMismatch = errors.New("cloner type mismatch")
+)
+
+// PanicError 生产者panic时，等待者得到的错误。
+type PanicError struct {
+	// Value recover()的结果。
+	Value interface{}
+
+	// Stack panic时生产者协程的调用栈。
+	Stack []byte
+}
+
+func newPanicError(value interface{}) *PanicError {
+	return &PanicError{
+		Value: value,
+		Stack: debug.Stack(),
+	}
+}
+
+// Error 实现error接口。
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("producer panicked: %v", e.Value)
+}
+
+// Unwrap 支持errors.Is(err, ErrProducerPanicked)。
+func (e *PanicError) Unwrap() error {
+	return ErrProducerPanicked
+}
+
+// NotFoundError 批量处理返回的map中没有key时，这个key得到的错误。
+type NotFoundError struct {
+	Key interface{}
+}
+
+// Error 实现error接口。
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("key not found: %v", e.Key)
+}
+
+// Unwrap 支持errors.Is(err, ErrNotFound)。
+func (e *NotFoundError) Unwrap() error {
+	return ErrNotFound
+}
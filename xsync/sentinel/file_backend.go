@@ -0,0 +1,239 @@
+//go:build unix
+
+package sentinel
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// fileLockStripes 锁分段文件的个数。
+const fileLockStripes = 64
+
+// FileBackend 基于文件锁的后端。用于同一主机上的多个进程。
+// 每个key对应目录下的一个锁文件和一个结果文件，文件名是key的哈希。
+// 锁文件的内容是失效时间和token；检查和修改锁文件时，持有key所在分段的分段文件的flock，多个进程之间互斥。
+// 分段文件的个数固定，不删除；锁文件在释放锁后删除。
+// 进程退出没有释放的锁文件和失效的结果文件，由Cleanup删除。
+type FileBackend struct {
+	dir string
+}
+
+// NewFileBackend 新建基于文件锁的后端。dir不存在时创建。
+func NewFileBackend(dir string) (*FileBackend, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileBackend{dir: dir}, nil
+}
+
+func (b *FileBackend) path(key string, ext string) string {
+	sum := sha1.Sum([]byte(key))
+	return filepath.Join(b.dir, hex.EncodeToString(sum[:])+ext)
+}
+
+// stripePath 哈希的第一个字节所在分段的分段文件。
+func (b *FileBackend) stripePath(hash byte) string {
+	return filepath.Join(b.dir, fmt.Sprintf("stripe-%02d.flock", int(hash)%fileLockStripes))
+}
+
+// withStripe 持有分段文件的flock时执行f。
+func (b *FileBackend) withStripe(hash byte, f func() error) error {
+	file, err := os.OpenFile(b.stripePath(hash), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := flock(file, syscall.LOCK_EX); err != nil {
+		return err
+	}
+	defer flock(file, syscall.LOCK_UN)
+
+	return f()
+}
+
+// withKeyStripe 持有key所在分段的flock时执行f。
+func (b *FileBackend) withKeyStripe(key string, f func() error) error {
+	sum := sha1.Sum([]byte(key))
+	return b.withStripe(sum[0], f)
+}
+
+// flock 对文件加锁或者解锁。被信号中断时重试。
+func flock(file *os.File, how int) error {
+	for {
+		err := syscall.Flock(int(file.Fd()), how)
+		if !errors.Is(err, syscall.EINTR) {
+			return err
+		}
+	}
+}
+
+// readDeadline 读取文件开头的失效时间。内容不足8个字节时，ok为false。
+func readDeadline(content []byte) (deadline time.Time, ok bool) {
+	if len(content) < 8 {
+		return time.Time{}, false
+	}
+	return time.Unix(0, int64(binary.BigEndian.Uint64(content))), true
+}
+
+// TryLock 尝试获得key的锁。锁文件的前8个字节是失效时间，后面是token。
+func (b *FileBackend) TryLock(ctx context.Context, key string, ttl time.Duration) (token string, acquired bool, err error) {
+	token, err = newToken()
+	if err != nil {
+		return "", false, err
+	}
+
+	path := b.path(key, ".lock")
+	err = b.withKeyStripe(key, func() error {
+		content, err := os.ReadFile(path)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		if deadline, ok := readDeadline(content); ok && time.Now().Before(deadline) {
+			return nil // 其它进程持有锁
+		}
+
+		newContent := make([]byte, 8, 8+len(token))
+		binary.BigEndian.PutUint64(newContent, uint64(time.Now().Add(ttl).UnixNano()))
+		newContent = append(newContent, token...)
+		if err := os.WriteFile(path, newContent, 0o644); err != nil {
+			return err
+		}
+		acquired = true
+		return nil
+	})
+	if err != nil || !acquired {
+		return "", false, err
+	}
+	return token, true, nil
+}
+
+// Unlock 释放key的锁，删除锁文件。锁文件的token不匹配时，不释放。
+func (b *FileBackend) Unlock(ctx context.Context, key string, token string) error {
+	path := b.path(key, ".lock")
+	return b.withKeyStripe(key, func() error {
+		content, err := os.ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if len(content) < 8 || string(content[8:]) != token {
+			return nil
+		}
+		return removeIfExist(path)
+	})
+}
+
+// Load 读取key的结果。结果文件的前8个字节是失效时间。
+func (b *FileBackend) Load(ctx context.Context, key string) (data []byte, found bool, err error) {
+	content, err := os.ReadFile(b.path(key, ".result"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if deadline, ok := readDeadline(content); !ok || !time.Now().Before(deadline) {
+		return nil, false, nil
+	}
+	return content[8:], true, nil
+}
+
+// Store 保存key的结果。先写临时文件，再重命名，其它进程不会读到写了一半的结果。
+func (b *FileBackend) Store(ctx context.Context, key string, data []byte, ttl time.Duration) error {
+	content := make([]byte, 8, 8+len(data))
+	binary.BigEndian.PutUint64(content, uint64(time.Now().Add(ttl).UnixNano()))
+	content = append(content, data...)
+
+	file, err := os.CreateTemp(b.dir, "result-*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(content)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return err
+	}
+	// 持有分段的flock，以免Cleanup删除刚保存的结果
+	err = b.withKeyStripe(key, func() error {
+		return os.Rename(file.Name(), b.path(key, ".result"))
+	})
+	if err != nil {
+		os.Remove(file.Name())
+		return err
+	}
+	return nil
+}
+
+// Delete 删除key的结果。
+func (b *FileBackend) Delete(ctx context.Context, key string) error {
+	return removeIfExist(b.path(key, ".result"))
+}
+
+// Cleanup 删除已经失效的锁文件和结果文件。
+// 使用无限的key时，应该定期调用，以免目录下的文件一直增加。
+func (b *FileBackend) Cleanup(ctx context.Context) error {
+	entries, err := os.ReadDir(b.dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		name := entry.Name()
+		ext := filepath.Ext(name)
+		if ext != ".lock" && ext != ".result" {
+			continue
+		}
+		hash, err := hex.DecodeString(strings.TrimSuffix(name, ext))
+		if err != nil || len(hash) != sha1.Size {
+			continue
+		}
+
+		path := filepath.Join(b.dir, name)
+		err = b.withStripe(hash[0], func() error {
+			content, err := os.ReadFile(path)
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if deadline, ok := readDeadline(content); ok && time.Now().Before(deadline) {
+				return nil
+			}
+			return removeIfExist(path)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeIfExist 删除文件。文件不存在时，不返回错误。
+func removeIfExist(path string) error {
+	err := os.Remove(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+//go:build unix
+
+package sentinel
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileBackend(t *testing.T) {
+	backend, err := NewFileBackend(t.TempDir())
+	if assert.Nil(t, err) {
+		testBackend(t, backend)
+	}
+}
+
+func TestFileBackend_ConcurrentlyTryLock(t *testing.T) {
+	dir := t.TempDir()
+
+	// 模拟多个进程，每个后端分别打开锁文件
+	var backends []*FileBackend
+	for i := 0; i < 8; i++ {
+		backend, err := NewFileBackend(dir)
+		if !assert.Nil(t, err) {
+			return
+		}
+		backends = append(backends, backend)
+	}
+
+	for round := 0; round < 5; round++ {
+		// 锁已经失效，同时尝试接管
+		var acquiredCount int32
+		var wg sync.WaitGroup
+		for _, backend := range backends {
+			wg.Add(1)
+			go func(backend *FileBackend) {
+				defer wg.Done()
+				_, acquired, err := backend.TryLock(context.TODO(), "key", time.Millisecond*100)
+				assert.Nil(t, err)
+				if acquired {
+					atomic.AddInt32(&acquiredCount, 1)
+				}
+			}(backend)
+		}
+		wg.Wait()
+		assert.Equal(t, int32(1), acquiredCount)
+
+		time.Sleep(time.Millisecond * 110)
+	}
+}
+
+func TestFileBackend_UnlockOther(t *testing.T) {
+	backend, err := NewFileBackend(t.TempDir())
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	// 锁失效后被其它进程获得，原持有者不能释放
+	token1, acquired, _ := backend.TryLock(context.TODO(), "key", time.Millisecond)
+	assert.True(t, acquired)
+	time.Sleep(time.Millisecond * 2)
+	_, acquired, _ = backend.TryLock(context.TODO(), "key", time.Second)
+	assert.True(t, acquired)
+	assert.Nil(t, backend.Unlock(context.TODO(), "key", token1))
+	_, acquired, _ = backend.TryLock(context.TODO(), "key", time.Second)
+	assert.False(t, acquired)
+
+	// 没有锁文件
+	assert.Nil(t, backend.Unlock(context.TODO(), "other", token1))
+}
+
+func TestFileBackend_Cleanup(t *testing.T) {
+	dir := t.TempDir()
+	backend, err := NewFileBackend(dir)
+	if !assert.Nil(t, err) {
+		return
+	}
+	ctx := context.TODO()
+
+	// 释放锁后删除锁文件，分段文件的个数固定
+	for i := 0; i < 200; i++ {
+		key := fmt.Sprintf("key%d", i)
+		token, acquired, err := backend.TryLock(ctx, key, time.Second)
+		if assert.Nil(t, err) && assert.True(t, acquired) {
+			assert.Nil(t, backend.Unlock(ctx, key, token))
+		}
+	}
+	lockFiles, _ := filepath.Glob(filepath.Join(dir, "*.lock"))
+	assert.Empty(t, lockFiles)
+	stripeFiles, _ := filepath.Glob(filepath.Join(dir, "*.flock"))
+	assert.LessOrEqual(t, len(stripeFiles), fileLockStripes)
+
+	// 没有释放的锁、保存的结果失效后删除
+	_, acquired, _ := backend.TryLock(ctx, "expired", time.Millisecond)
+	assert.True(t, acquired)
+	_, acquired, _ = backend.TryLock(ctx, "held", time.Minute)
+	assert.True(t, acquired)
+	assert.Nil(t, backend.Store(ctx, "expired", []byte("hello"), time.Millisecond))
+	assert.Nil(t, backend.Store(ctx, "valid", []byte("hello"), time.Minute))
+	time.Sleep(time.Millisecond * 2)
+
+	assert.Nil(t, backend.Cleanup(ctx))
+	assert.NoFileExists(t, backend.path("expired", ".lock"))
+	assert.NoFileExists(t, backend.path("expired", ".result"))
+	assert.FileExists(t, backend.path("held", ".lock"))
+	data, found, _ := backend.Load(ctx, "valid")
+	if assert.True(t, found) {
+		assert.Equal(t, "hello", string(data))
+	}
+	_, acquired, _ = backend.TryLock(ctx, "held", time.Minute)
+	assert.False(t, acquired)
+}
@@ -0,0 +1,114 @@
+package sentinel
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// flight 独立执行模式下的一次执行。Do执行一个key，MDo执行一批key。
+// 记录还在等待结果的调用者个数，全部调用者都放弃等待时，取消执行。
+type flight struct {
+	mu sync.Mutex
+
+	// waiters 还在等待结果的调用者个数。同一调用者等待多个key时，计多次。
+	waiters int
+
+	// abandoned 全部调用者都已经放弃等待。
+	abandoned bool
+
+	cancel context.CancelFunc
+}
+
+// newFlight 新建一次执行。执行者计为一个等待者。
+func newFlight() *flight {
+	return &flight{waiters: 1}
+}
+
+// start 创建执行使用的context。
+// context保留parent的Value，但不随parent取消；timeout>0时，限制执行时长。
+func (fl *flight) start(parent context.Context, timeout time.Duration) context.Context {
+	ctx := context.Context(detachedContext{parent: parent})
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+
+	fl.mu.Lock()
+	defer fl.mu.Unlock()
+
+	fl.cancel = cancel
+	if fl.abandoned {
+		cancel()
+	}
+	return ctx
+}
+
+// join 增加等待者。如果全部调用者已经放弃等待，返回false。
+func (fl *flight) join(n int) bool {
+	fl.mu.Lock()
+	defer fl.mu.Unlock()
+
+	if fl.abandoned {
+		return false
+	}
+	fl.waiters += n
+	return true
+}
+
+// leave 等待者放弃等待。如果全部调用者都已经放弃等待，取消执行，返回true。
+func (fl *flight) leave() bool {
+	fl.mu.Lock()
+	defer fl.mu.Unlock()
+
+	fl.waiters--
+	if fl.waiters > 0 || fl.abandoned {
+		return false
+	}
+	fl.abandoned = true
+	if fl.cancel != nil {
+		fl.cancel()
+	}
+	return true
+}
+
+// isAbandoned 全部调用者是否都已经放弃等待。
+func (fl *flight) isAbandoned() bool {
+	fl.mu.Lock()
+	defer fl.mu.Unlock()
+
+	return fl.abandoned
+}
+
+// finish 执行完成，释放context。
+func (fl *flight) finish() {
+	fl.mu.Lock()
+	defer fl.mu.Unlock()
+
+	if fl.cancel != nil {
+		fl.cancel()
+	}
+}
+
+// detachedContext 保留parent的Value，但不继承parent的取消和截止时间。
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (deadline time.Time, ok bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+func (c detachedContext) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
+}
@@ -0,0 +1,790 @@
+package sentinel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// GroupDoFunc Group执行的函数。
+type GroupDoFunc[V any] func(ctx context.Context) (V, error)
+
+// GroupMDoFunc Group批量处理时执行的函数。
+// 返回的[]V和[]error的顺序同keys；[]error表示各个下标位置上的错误，可以为nil，或者省去后面的nil。
+// 有错误的位置上，[]V的元素被忽略；后面都有错误时，[]V可以省去后面的元素。
+type GroupMDoFunc[K comparable, V any] func(ctx context.Context, keys []K) ([]V, []error, error)
+
+// GroupMDoMapFunc Group按key返回结果的批量处理时执行的函数。
+// 返回key到结果、key到错误的map。两个map中都没有的key，得到*NotFoundError。
+type GroupMDoMapFunc[K comparable, V any] func(ctx context.Context, keys []K) (map[K]V, map[K]error, error)
+
+// GroupMDoStreamFunc Group流式批量处理时执行的函数。
+// 每得到一个key的结果，调用emit提交，立即通知等待这个key的调用者。index是key在keys中的下标。
+// emit可以并发调用；同一个key重复提交、f返回后提交，都被忽略。
+// f返回后，没有提交结果的key得到f返回的error；f返回nil时，得到错误"not enough results"。
+type GroupMDoStreamFunc[K comparable, V any] func(ctx context.Context, keys []K, emit func(index int, value V, err error)) error
+
+// Result Group.DoChan的结果。
+type Result[V any] struct {
+	Val V
+	Err error
+
+	// Shared 结果是否还给了其它调用者。
+	Shared bool
+}
+
+// MResult Group.MDoChan的结果。
+type MResult[V any] struct {
+	Vals []V
+	Errs []error
+	Err  error
+
+	// Shared 是否有结果还给了其它调用者。
+	Shared bool
+}
+
+// call 一个key的一次执行。
+type call[V any] struct {
+	*TypedSentinel[V]
+
+	// flight 独立执行模式下，所属的执行。否则为nil。
+	flight *flight
+
+	// dups 等待这次执行的结果的其它调用者个数。
+	dups int32
+
+	// delivered 独立执行模式下，是否有调用者得到了结果。
+	delivered int32
+}
+
+// Group 泛型的哨兵组。同SentinelGroup，但不使用反射。
+// 零值可用。默认在调用Delete前一直保留结果。
+type Group[K comparable, V any] struct {
+	stats groupStats
+
+	opts options
+
+	// cloner 复制结果。为nil时，按opts复制。
+	cloner func(V) V
+
+	// calls 按key分片的哨兵表。
+	calls callMap[K, V]
+}
+
+// NewGroup 新建泛型的哨兵组。
+// WithCloner的函数的类型不是func(V) V时，调用者得到ErrClonerMismatch；使用NewGroupWithCloner可以在编译时检查。
+func NewGroup[K comparable, V any](opts ...Option) *Group[K, V] {
+	g := &Group[K, V]{}
+	g.setOptions(newOptions(opts...))
+	return g
+}
+
+// NewGroupWithCloner 新建泛型的哨兵组。每个调用者都得到clone复制的结果，可以修改。包括执行的调用者。
+// 覆盖选项中的WithCloner和WithCloneMethod。
+func NewGroupWithCloner[K comparable, V any](clone func(V) V, opts ...Option) *Group[K, V] {
+	g := &Group[K, V]{}
+	g.setOptions(newOptions(opts...))
+	g.cloner = clone
+	return g
+}
+
+// setOptions 设置选项。WithCloner的函数的类型是func(V) V时，直接使用。
+func (g *Group[K, V]) setOptions(o options) {
+	g.opts = o
+	if clone, ok := o.typedCloner.(func(V) V); ok {
+		g.cloner = clone
+	}
+}
+
+// newFlight 独立执行模式下，新建一次执行。否则返回nil。
+func (g *Group[K, V]) newFlight() *flight {
+	if !g.opts.detached {
+		return nil
+	}
+	return newFlight()
+}
+
+// producerStart 开始执行key的逻辑。返回开始时间。
+func (g *Group[K, V]) producerStart(key K) time.Time {
+	atomic.AddInt64(&g.stats.executions, 1)
+	atomic.AddInt64(&g.stats.inFlight, 1)
+	if g.opts.observer != nil {
+		g.opts.observer.OnProducerStart(key)
+	}
+	return time.Now()
+}
+
+// producerFinish key的逻辑执行完成。
+func (g *Group[K, V]) producerFinish(key K, start time.Time, err error) {
+	atomic.AddInt64(&g.stats.inFlight, -1)
+	if err != nil {
+		atomic.AddInt64(&g.stats.errors, 1)
+	}
+	if g.opts.observer != nil {
+		g.opts.observer.OnProducerFinish(key, time.Since(start), err)
+	}
+}
+
+// waiterJoin 调用者等待其它过程执行key的结果。
+func (g *Group[K, V]) waiterJoin(key K) {
+	atomic.AddInt64(&g.stats.coalescedWaits, 1)
+	if g.opts.observer != nil {
+		g.opts.observer.OnWaiterJoin(key)
+	}
+}
+
+// waiterTimeout 调用者放弃等待key的结果。
+func (g *Group[K, V]) waiterTimeout(key K, err error) {
+	if g.opts.observer != nil {
+		g.opts.observer.OnWaiterTimeout(key, err)
+	}
+}
+
+// Stats 返回统计。
+func (g *Group[K, V]) Stats() Stats {
+	return g.stats.snapshot()
+}
+
+// clone 按选项复制没有错误的结果。
+// 复制的结果的类型不是V时，返回ErrClonerMismatch。
+func (g *Group[K, V]) clone(value V, err error) (V, error) {
+	if err != nil {
+		return value, err
+	}
+	if g.cloner != nil {
+		return g.cloner(value), nil
+	}
+	if g.opts.cloner == nil {
+		return value, nil
+	}
+
+	var zero V
+	cloned, err := g.opts.cloner(value)
+	if err != nil {
+		return zero, err
+	}
+	if cloned == nil {
+		return zero, nil
+	}
+	result, ok := cloned.(V)
+	if !ok {
+		return zero, fmt.Errorf("%w: result of %T, cloned to %T", ErrClonerMismatch, value, cloned)
+	}
+	return result, nil
+}
+
+// remove 如果key的哨兵还是c，删除它。
+func (g *Group[K, V]) remove(key K, c *call[V]) {
+	g.calls.remove(key, c)
+}
+
+// release 执行完成后，按选项删除key。
+// 如果全部调用者都已经放弃等待，并且没有调用者得到结果，立即删除key。
+func (g *Group[K, V]) release(key K, c *call[V]) {
+	if c.flight != nil && c.flight.isAbandoned() && atomic.LoadInt32(&c.delivered) == 0 {
+		g.remove(key, c)
+		return
+	}
+
+	if !g.opts.release {
+		return
+	}
+	if g.opts.retention > 0 {
+		time.AfterFunc(g.opts.retention, func() {
+			g.remove(key, c)
+		})
+		return
+	}
+	g.remove(key, c)
+}
+
+// settle 提交key的结果，然后按选项删除key。
+// 违反调用约定的错误（结果不够、参数不对）不保留，同panic，立即删除key，下次重新执行。
+func (g *Group[K, V]) settle(key K, c *call[V], value V, err error) {
+	c.Done(value, err)
+	if isContractError(err) {
+		g.remove(key, c)
+		return
+	}
+	g.release(key, c)
+}
+
+// wait 等待其它过程的执行结果。
+// 独立执行模式下，得到结果或者放弃等待后，不再计为等待者；如果全部调用者都不再等待，取消执行。
+func (g *Group[K, V]) wait(ctx context.Context, key K, c *call[V]) (V, error) {
+	if g.opts.waitTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, g.opts.waitTimeout)
+		defer cancel()
+	}
+
+	select {
+	case <-c.flag:
+	case <-ctx.Done():
+		select {
+		case <-c.flag: // 已经完成
+		default:
+			if c.flight != nil && c.flight.leave() {
+				g.remove(key, c)
+			}
+			g.waiterTimeout(key, ctx.Err())
+			var zero V
+			return zero, ctx.Err()
+		}
+	}
+
+	if c.flight != nil {
+		// 已经得到结果，不再计为等待者
+		// 批量处理时，其它key的调用者都放弃等待后，取消执行
+		atomic.StoreInt32(&c.delivered, 1)
+		c.flight.leave()
+	}
+	return c.Wait(context.Background())
+}
+
+// waitOrDo 等待其它过程的执行结果。
+// 设置了WithWaitOrDo时，等待一段时间后还没有结果，自己执行f，返回最先得到的结果。
+// 自己执行计入Stats的Executions和Hedges，并通知观察者。
+func (g *Group[K, V]) waitOrDo(ctx context.Context, key K, c *call[V], f GroupDoFunc[V]) (V, error) {
+	if g.opts.hedgeDelay <= 0 {
+		return g.wait(ctx, key, c)
+	}
+	if g.opts.waitTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, g.opts.waitTimeout)
+		defer cancel()
+	}
+
+	timer := time.NewTimer(g.opts.hedgeDelay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-c.flag:
+		return g.wait(ctx, key, c)
+	case <-ctx.Done():
+		return g.wait(ctx, key, c)
+	}
+
+	// 自己执行
+	hedgeCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	hedgeCh := make(chan Result[V], 1)
+	atomic.AddInt64(&g.stats.hedges, 1)
+	start := g.producerStart(key)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				panicErr := newPanicError(r)
+				g.producerFinish(key, start, panicErr)
+				hedgeCh <- Result[V]{Err: panicErr}
+			}
+		}()
+
+		result, err := retryDo(g.opts.retry, f)(hedgeCtx)
+		g.producerFinish(key, start, err)
+		hedgeCh <- Result[V]{Val: result, Err: err}
+	}()
+
+	select {
+	case <-c.flag:
+		return g.wait(ctx, key, c)
+	case hedged := <-hedgeCh:
+		// 不再等待
+		if c.flight != nil && c.flight.leave() {
+			g.remove(key, c)
+		}
+		return hedged.Val, hedged.Err
+	case <-ctx.Done():
+		return g.wait(ctx, key, c)
+	}
+}
+
+// Do key删除（或者按选项释放）前，不重复执行key相同的逻辑。
+// 返回的结果是共享的，不可修改；除非设置了WithCloner或者WithCloneMethod。
+// 如果f panic，执行f的调用者再次panic，等待者得到*PanicError，key被删除，下次重新执行。
+// 独立执行模式下，f在新协程中执行，全部调用者都得到*PanicError。
+func (g *Group[K, V]) Do(ctx context.Context, key K, f GroupDoFunc[V]) (V, error) {
+	result, _, err := g.doCall(ctx, key, g.distributedDo(key, f), f)
+	return result, err
+}
+
+// DoChan 同Do，但不阻塞，返回接收结果的通道。通道只接收一个结果。
+// 如果f panic，结果的Err为*PanicError。
+func (g *Group[K, V]) DoChan(ctx context.Context, key K, f GroupDoFunc[V]) <-chan Result[V] {
+	ch := make(chan Result[V], 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				ch <- Result[V]{Err: newPanicError(r)}
+			}
+		}()
+
+		result, shared, err := g.doCall(ctx, key, g.distributedDo(key, f), f)
+		ch <- Result[V]{Val: result, Err: err, Shared: shared}
+	}()
+	return ch
+}
+
+// doCall 执行或者等待执行key的逻辑。shared表示结果是否由多个调用者共享。
+// f是执行的函数，设置WithDistributed时通过后端协调；hedge是等待者自己执行的函数，不通过后端协调。
+func (g *Group[K, V]) doCall(ctx context.Context, key K, f GroupDoFunc[V], hedge GroupDoFunc[V]) (result V, shared bool, err error) {
+	c := &call[V]{TypedSentinel: NewTypedSentinel[V](), flight: g.newFlight()}
+	actual, loaded := g.calls.loadOrStore(key, c)
+	if loaded {
+		// 由其它过程执行
+		// 这里等待其它逻辑的执行结果
+		g.waiterJoin(key)
+		result, err = g.waitOrDo(ctx, key, actual, hedge)
+		result, err = g.clone(result, err)
+		return result, true, err
+	}
+
+	// do it
+	if c.flight == nil {
+		result, err = g.do(ctx, key, c, f)
+		result, err = g.clone(result, err)
+		return result, atomic.LoadInt32(&c.dups) > 0, err
+	}
+
+	// 独立执行
+	doCtx := c.flight.start(ctx, g.opts.maxExecution)
+	go func() {
+		defer c.flight.finish()
+		defer func() {
+			_ = recover() // 已经通过*PanicError通知全部调用者
+		}()
+
+		g.do(doCtx, key, c, f)
+	}()
+	result, err = g.wait(ctx, key, c)
+	result, err = g.clone(result, err)
+	return result, atomic.LoadInt32(&c.dups) > 0, err
+}
+
+// do 执行函数f，提交结果。
+// 如果f panic，等待者得到*PanicError，删除key，然后再次panic。
+func (g *Group[K, V]) do(ctx context.Context, key K, c *call[V], f GroupDoFunc[V]) (result V, err error) {
+	start := g.producerStart(key)
+	defer func() {
+		if r := recover(); r != nil {
+			var zero V
+			panicErr := newPanicError(r)
+			g.producerFinish(key, start, panicErr)
+			c.Done(zero, panicErr)
+			g.remove(key, c)
+			panic(r)
+		}
+		if !c.isDone() {
+			// 没有返回，也没有panic。例如runtime.Goexit()
+			g.producerFinish(key, start, ErrAbandoned)
+			c.Close()
+			g.remove(key, c)
+		}
+	}()
+
+	result, err = retryDo(g.opts.retry, f)(ctx)
+	g.producerFinish(key, start, err)
+	c.Done(result, err)
+	g.release(key, c)
+	return result, err
+}
+
+// MDo 处理一批数据。key删除（或者按选项释放）前，不重复执行key相同的逻辑。
+// 函数f的参数是需要执行的key，顺序同keys。
+// 返回的[]V和[]error的顺序和长度等于keys的顺序和长度。有错误的位置上，[]V的元素为零值。
+// 如果没有错误，[]error为nil。只有函数f的结果不合格时，才会返回error。
+// 返回的[]V内各元素的数据是共享的，不可修改；除非设置了WithCloner或者WithCloneMethod。
+// 如果f panic，处理同Do。
+// 独立执行模式下，f的结果不合格时，不返回error，各个位置上都是错误。
+func (g *Group[K, V]) MDo(ctx context.Context, keys []K, f GroupMDoFunc[K, V]) ([]V, []error, error) {
+	results, errs, _, err := g.mdoCall(ctx, keys, g.batch(g.distributedMDo(f)))
+	return results, errs, err
+}
+
+// MDoMap 同MDo，但函数f按key返回结果。f返回的结果中没有的key，得到*NotFoundError。
+func (g *Group[K, V]) MDoMap(ctx context.Context, keys []K, f GroupMDoMapFunc[K, V]) ([]V, []error, error) {
+	results, errs, _, err := g.mdoCall(ctx, keys, g.batch(g.distributedMDo(func(ctx context.Context, doKeys []K) ([]V, []error, error) {
+		valueMap, errMap, err := f(ctx, doKeys)
+		if err != nil {
+			return nil, nil, err
+		}
+		values, errs := fromMaps(doKeys, valueMap, errMap)
+		return values, errs, nil
+	})))
+	return results, errs, err
+}
+
+// MDoStream 同MDo，但使用流式批量处理的函数f。
+// f每提交一个key的结果，立即通知等待这个key的其它调用者，不等待整批处理完成。
+// 本次调用在全部key都有结果后返回。
+func (g *Group[K, V]) MDoStream(ctx context.Context, keys []K, f GroupMDoStreamFunc[K, V]) ([]V, []error, error) {
+	results, errs, _, err := g.mdoCall(ctx, keys, g.streamBatch(f))
+	return results, errs, err
+}
+
+// MDoChan 同MDo，但不阻塞，返回接收结果的通道。通道只接收一个结果。
+// 如果f panic，结果的Err为*PanicError。
+func (g *Group[K, V]) MDoChan(ctx context.Context, keys []K, f GroupMDoFunc[K, V]) <-chan MResult[V] {
+	ch := make(chan MResult[V], 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				ch <- MResult[V]{Err: newPanicError(r)}
+			}
+		}()
+
+		results, errs, shared, err := g.mdoCall(ctx, keys, g.batch(g.distributedMDo(f)))
+		ch <- MResult[V]{Vals: results, Errs: errs, Err: err, Shared: shared}
+	}()
+	return ch
+}
+
+// mdoCall 执行或者等待执行一批key的逻辑。shared表示是否有结果由多个调用者共享。
+func (g *Group[K, V]) mdoCall(ctx context.Context, keys []K, run batchFunc[K, V]) (results []V, errs []error, shared bool, err error) {
+	if len(keys) == 0 {
+		return nil, nil, false, nil
+	}
+
+	// 同一次调用中重复的key只处理一次，不计为等待其它调用者
+	uniqueKeys, positions := dedupKeys(keys)
+
+	var waitCallMap = make(map[int]*call[V])
+	var doIndexes []int
+	var doKeys []K
+	var doCalls []*call[V]
+
+	var loadedCount int // 等待其它过程执行的key的个数
+
+	fl := g.newFlight()
+	// 每个分片只加锁一次
+	actuals, loadeds := g.calls.loadOrStoreBatch(uniqueKeys, func() *call[V] {
+		return &call[V]{TypedSentinel: NewTypedSentinel[V](), flight: fl}
+	})
+	for index, key := range uniqueKeys {
+		if loadeds[index] {
+			// 由其它过程执行
+			// 这里等待其它逻辑的执行结果
+			waitCallMap[index] = actuals[index]
+			loadedCount++
+			g.waiterJoin(key)
+		} else {
+			// 需要下面执行的
+			doIndexes = append(doIndexes, index)
+			doKeys = append(doKeys, key)
+			doCalls = append(doCalls, actuals[index])
+		}
+	}
+
+	uniqueResults := make([]V, len(uniqueKeys))
+	uniqueErrs := make([]error, len(uniqueKeys))
+
+	// 执行函数f
+	if len(doKeys) > 0 {
+		if fl == nil {
+			values, doErrs, err := run(ctx, doKeys, doCalls)
+			if err != nil {
+				return nil, nil, false, err
+			}
+			for i, index := range doIndexes {
+				if doErrs[i] != nil {
+					uniqueErrs[index] = doErrs[i]
+				} else {
+					uniqueResults[index] = values[i]
+				}
+			}
+		} else {
+			// 独立执行
+			// 执行者对每个key计为一个等待者
+			fl.join(len(doKeys) - 1)
+			doCtx := fl.start(ctx, g.opts.maxExecution)
+			go func() {
+				defer fl.finish()
+				defer func() {
+					_ = recover() // 已经通过*PanicError通知全部调用者
+				}()
+
+				run(doCtx, doKeys, doCalls)
+			}()
+			for i, index := range doIndexes {
+				waitCallMap[index] = doCalls[i]
+			}
+		}
+	}
+
+	//  等待其它过程完成
+	for index, c := range waitCallMap {
+		value, err := g.wait(ctx, uniqueKeys[index], c)
+		if err != nil {
+			uniqueErrs[index] = err
+		} else {
+			uniqueResults[index] = value
+		}
+	}
+
+	// 每个位置上都复制结果
+	results = make([]V, len(keys))
+	errs = make([]error, len(keys))
+	var hasErr bool
+	for index, position := range positions {
+		results[index], errs[index] = g.clone(uniqueResults[position], uniqueErrs[position])
+		if errs[index] != nil {
+			hasErr = true
+		}
+	}
+
+	shared = loadedCount > 0
+	for _, c := range doCalls {
+		if atomic.LoadInt32(&c.dups) > 0 {
+			shared = true
+		}
+	}
+
+	if hasErr {
+		return results, errs, shared, nil
+	}
+	return results, nil, shared, nil
+}
+
+// distributedDo 按选项通过后端协调执行f。
+func (g *Group[K, V]) distributedDo(key K, f GroupDoFunc[V]) GroupDoFunc[V] {
+	d := g.opts.distributed
+	if d == nil {
+		return f
+	}
+	return distributedDo(d, key, f, decodeAs[V](d.codec))
+}
+
+// distributedMDo 按选项通过后端协调批量执行f。
+func (g *Group[K, V]) distributedMDo(f GroupMDoFunc[K, V]) GroupMDoFunc[K, V] {
+	d := g.opts.distributed
+	if d == nil {
+		return f
+	}
+	return distributedMDo(d, f, decodeAs[V](d.codec))
+}
+
+// batchFunc 执行一批key的逻辑，提交各个key的结果。
+// 返回的[]V和[]error的顺序和长度等于doKeys的顺序和长度。
+type batchFunc[K comparable, V any] func(ctx context.Context, doKeys []K, doCalls []*call[V]) ([]V, []error, error)
+
+// batch 返回执行f的batchFunc。
+func (g *Group[K, V]) batch(f GroupMDoFunc[K, V]) batchFunc[K, V] {
+	return func(ctx context.Context, doKeys []K, doCalls []*call[V]) ([]V, []error, error) {
+		return g.mdo(ctx, doKeys, doCalls, f)
+	}
+}
+
+// mdo 执行批量处理的函数f，提交各个key的结果。
+// 返回的[]V和[]error的顺序和长度等于doKeys的顺序和长度。
+// 如果f panic，等待者得到*PanicError，删除key，然后再次panic。
+func (g *Group[K, V]) mdo(ctx context.Context, doKeys []K, doCalls []*call[V], f GroupMDoFunc[K, V]) ([]V, []error, error) {
+	start := time.Now()
+	for _, key := range doKeys {
+		g.producerStart(key)
+	}
+
+	// 清理
+	defer func() {
+		r := recover()
+		g.abortBatch(doKeys, doCalls, start, r)
+		if r != nil {
+			panic(r)
+		}
+	}()
+
+	values, doErrs, err := retryMDo(g.opts.retry, f)(ctx, doKeys)
+	if err == nil && !enoughResults(values, doErrs, len(doKeys)) {
+		err = errNotEnoughResults
+	}
+
+	results := make([]V, len(doKeys))
+	errs := make([]error, len(doKeys))
+	for i, c := range doCalls {
+		var value V
+		var elemErr error
+		switch {
+		case err != nil:
+			// 每个位置上都是错误
+			elemErr = err
+		case len(doErrs) > i && doErrs[i] != nil: // 允许省去后面的nil
+			elemErr = doErrs[i]
+		default:
+			value = values[i]
+		}
+		results[i], errs[i] = value, elemErr
+
+		// 通知其它在等待的过程
+		g.producerFinish(doKeys[i], start, elemErr)
+		g.settle(doKeys[i], c, value, elemErr)
+	}
+
+	if err == errNotEnoughResults {
+		return nil, nil, err
+	}
+	return results, errs, nil
+}
+
+// streamBatch 返回执行流式批量处理的函数f的batchFunc。
+func (g *Group[K, V]) streamBatch(f GroupMDoStreamFunc[K, V]) batchFunc[K, V] {
+	return func(ctx context.Context, doKeys []K, doCalls []*call[V]) ([]V, []error, error) {
+		return g.mdoStream(ctx, doKeys, doCalls, f)
+	}
+}
+
+// mdoStream 执行流式批量处理的函数f。每个key提交结果后，立即通知等待者。
+// 返回的[]V和[]error的顺序和长度等于doKeys的顺序和长度。
+// 如果f panic，没有提交结果的key的等待者得到*PanicError，删除key，然后再次panic。
+func (g *Group[K, V]) mdoStream(ctx context.Context, doKeys []K, doCalls []*call[V], f GroupMDoStreamFunc[K, V]) ([]V, []error, error) {
+	start := time.Now()
+	for _, key := range doKeys {
+		g.producerStart(key)
+	}
+
+	results := make([]V, len(doKeys))
+	errs := make([]error, len(doKeys))
+	var mu sync.Mutex
+	var returned bool // f已经返回，不再接受提交
+	emit := func(index int, value V, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if returned || index < 0 || index >= len(doKeys) || doCalls[index].isDone() {
+			return
+		}
+		results[index], errs[index] = value, err
+
+		// 通知其它在等待的过程
+		g.producerFinish(doKeys[index], start, err)
+		g.settle(doKeys[index], doCalls[index], value, err)
+	}
+
+	// 清理
+	defer func() {
+		r := recover()
+		mu.Lock()
+		returned = true
+		mu.Unlock()
+		g.abortBatch(doKeys, doCalls, start, r)
+		if r != nil {
+			panic(r)
+		}
+	}()
+
+	err := f(ctx, doKeys, emit)
+
+	mu.Lock()
+	defer mu.Unlock()
+	returned = true
+
+	// 没有提交结果的key
+	if err == nil {
+		err = errNotEnoughResults
+	}
+	for i, c := range doCalls {
+		if c.isDone() {
+			continue
+		}
+		errs[i] = err
+		g.producerFinish(doKeys[i], start, err)
+		var zero V
+		g.settle(doKeys[i], c, zero, err)
+	}
+	return results, errs, nil
+}
+
+// abortBatch 处理批量执行中没有提交结果的key，删除它们。
+// r不为nil时，等待者得到*PanicError；否则等待者得到ErrAbandoned。
+func (g *Group[K, V]) abortBatch(doKeys []K, doCalls []*call[V], start time.Time, r interface{}) {
+	var panicErr error
+	if r != nil {
+		panicErr = newPanicError(r)
+	}
+	for i, c := range doCalls {
+		if c.isDone() {
+			continue
+		}
+		if r != nil {
+			var zero V
+			g.producerFinish(doKeys[i], start, panicErr)
+			c.Done(zero, panicErr)
+		} else {
+			g.producerFinish(doKeys[i], start, ErrAbandoned)
+			c.Close() // 没有返回，也没有panic。例如runtime.Goexit()
+		}
+		g.remove(doKeys[i], c)
+	}
+}
+
+// Forget 删除key对应的哨兵。
+// 正在执行的逻辑继续执行，已经在等待的调用者仍得到它的结果；之后的调用者重新执行该key的逻辑。
+func (g *Group[K, V]) Forget(key K) {
+	g.Delete(key)
+}
+
+// Delete 删除key对应的哨兵。下次需要重新执行该key的逻辑。
+// 设置WithDistributed时，同时删除后端保存的结果。
+func (g *Group[K, V]) Delete(keys ...K) {
+	g.calls.delete(keys...)
+	if d := g.opts.distributed; d != nil {
+		for _, key := range keys {
+			d.delete(backendKeyOf(d, key))
+		}
+	}
+}
+
+var errNotEnoughResults = errors.New("not enough results")
+
+// isContractError 是否是违反调用约定的错误：结果不够，或者参数不对。
+func isContractError(err error) bool {
+	var fatalErr *fatalError
+	return errors.Is(err, errNotEnoughResults) || errors.As(err, &fatalErr)
+}
+
+// fromMaps 按keys的顺序，从map取出各个key的结果和错误。
+// 两个map中都没有的key，得到*NotFoundError。
+func fromMaps[K comparable, V any](keys []K, valueMap map[K]V, errMap map[K]error) ([]V, []error) {
+	values := make([]V, len(keys))
+	errs := make([]error, len(keys))
+	for i, key := range keys {
+		if err := errMap[key]; err != nil {
+			errs[i] = err
+			continue
+		}
+		value, ok := valueMap[key]
+		if !ok {
+			errs[i] = &NotFoundError{Key: key}
+			continue
+		}
+		values[i] = value
+	}
+	return values, errs
+}
+
+// dedupKeys 去掉重复的key。positions是keys的各个key在uniqueKeys中的下标。
+func dedupKeys[K comparable](keys []K) (uniqueKeys []K, positions []int) {
+	firstPositions := make(map[K]int, len(keys))
+	positions = make([]int, len(keys))
+	for index, key := range keys {
+		position, ok := firstPositions[key]
+		if !ok {
+			position = len(uniqueKeys)
+			uniqueKeys = append(uniqueKeys, key)
+			firstPositions[key] = position
+		}
+		positions[index] = position
+	}
+	return uniqueKeys, positions
+}
+
+// enoughResults 没有错误的位置上，是否都有结果。
+func enoughResults[V any](values []V, errs []error, count int) bool {
+	for i := len(values); i < count; i++ {
+		if len(errs) <= i || errs[i] == nil {
+			return false
+		}
+	}
+	return true
+}
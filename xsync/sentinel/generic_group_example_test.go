@@ -0,0 +1,74 @@
+package sentinel
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+func ExampleGroup_Do() {
+	var g Group[string, string]
+
+	var key = "hello"
+	resp, err := g.Do(context.TODO(), key, func(ctx context.Context) (string, error) {
+		return "echo: Hello", nil
+	})
+	defer g.Delete(key)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	fmt.Println(resp)
+	// Output: echo: Hello
+}
+
+func ExampleGroup_MDo() {
+	var g Group[int, string]
+
+	var keys = []int{1, 2, 3}
+	resp, errs, err := g.MDo(context.TODO(), keys, func(ctx context.Context, keys []int) ([]string, []error, error) {
+		var resp []string
+		for _, key := range keys {
+			resp = append(resp, fmt.Sprintf("echo: %d", key))
+		}
+		return resp, nil, nil
+	})
+	defer g.Delete(keys...)
+
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for idx, res := range resp {
+		if errs != nil && errs[idx] != nil {
+			fmt.Println("partial failure:", errs[idx])
+			continue
+		}
+		fmt.Println(res)
+	}
+
+	// Output: echo: 1
+	// echo: 2
+	// echo: 3
+}
+
+func ExampleBatcher_Load() {
+	b := NewBatcher(func(ctx context.Context, keys []int) ([]string, []error, error) {
+		// 一次查询全部key
+		var resp []string
+		for _, key := range keys {
+			resp = append(resp, fmt.Sprintf("echo: %d", key))
+		}
+		return resp, nil, nil
+	}, time.Millisecond*10, 100)
+
+	resp, err := b.Load(context.TODO(), 1)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	fmt.Println(resp)
+	// Output: echo: 1
+}
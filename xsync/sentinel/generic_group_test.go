@@ -0,0 +1,862 @@
+package sentinel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroup_SimpleDo(t *testing.T) {
+	var count int
+	fDo := func(ctx context.Context) (string, error) {
+		s := fmt.Sprintf("count: %d", count)
+		count++
+		return s, nil
+	}
+
+	var g Group[int, string]
+
+	// 第一次，要执行函数
+	result, err := g.Do(context.TODO(), 123, fDo)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "count: 0", result)
+	}
+
+	// 重复，直接取结果
+	result, err = g.Do(context.TODO(), 123, fDo)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "count: 0", result)
+	}
+
+	// 删除key，再重复
+	// 要重新执行函数
+	g.Delete(123)
+	result, err = g.Do(context.TODO(), 123, fDo)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "count: 1", result)
+	}
+
+	// 出错
+	_, err = g.Do(context.TODO(), 456, func(ctx context.Context) (string, error) {
+		return "", errors.New("test")
+	})
+	assert.NotNil(t, err)
+}
+
+func TestGroup_ConcurrentlyDo(t *testing.T) {
+	type Key struct {
+		Index int
+	}
+
+	var big = 1000
+	var g Group[Key, string]
+	var flags = make([]uint64, big)
+
+	rand.Seed(time.Now().UnixNano())
+
+	var wg sync.WaitGroup
+	wg.Add(100)
+	for i := 0; i < 100; i++ {
+		go func() {
+			defer wg.Done()
+
+			for _, index := range rand.Perm(big) {
+				index := index
+				want := fmt.Sprintf("index: %d, flag: %d", index, 1)
+
+				resp, err := g.Do(context.TODO(), Key{Index: index}, func(ctx context.Context) (string, error) {
+					flag := atomic.AddUint64(&flags[index], 1) // 记下每个index被处理的次数
+					return fmt.Sprintf("index: %d, flag: %d", index, flag), nil
+				})
+				if assert.Nil(t, err, "index: %d", index) {
+					if want != resp {
+						assert.Equal(t, want, resp)
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestGroup_MDo(t *testing.T) {
+	var g Group[string, string]
+
+	var count int
+	fDo := func(ctx context.Context, keys []string) ([]string, []error, error) {
+		var results []string
+		var errs []error
+		for _, key := range keys {
+			if key == "notfound" {
+				results = append(results, "")
+				errs = append(errs, errors.New("notfound"))
+				continue
+			}
+			results = append(results, fmt.Sprintf("echo: %s, count: %d", key, count))
+			errs = append(errs, nil)
+			count++
+		}
+		return results, errs, nil
+	}
+
+	// 第一次
+	results, errs, err := g.MDo(context.TODO(), []string{"one"}, fDo)
+	if assert.Nil(t, err) {
+		assert.Nil(t, errs)
+		assert.Equal(t, []string{"echo: one, count: 0"}, results)
+	}
+
+	// wait第一次保存的结果 + 第二次执行
+	results, errs, err = g.MDo(context.TODO(), []string{"two", "one"}, fDo)
+	if assert.Nil(t, err) {
+		assert.Nil(t, errs)
+		assert.Equal(t, []string{"echo: two, count: 1", "echo: one, count: 0"}, results)
+	}
+
+	// 部分错误
+	results, errs, err = g.MDo(context.TODO(), []string{"one", "notfound", "three"}, fDo)
+	if assert.Nil(t, err) {
+		if assert.Len(t, errs, 3) {
+			assert.Nil(t, errs[0])
+			assert.NotNil(t, errs[1])
+			assert.Nil(t, errs[2])
+		}
+		assert.Equal(t, []string{"echo: one, count: 0", "", "echo: three, count: 2"}, results)
+	}
+
+	// 全部错误
+	_, errs, err = g.MDo(context.TODO(), []string{"do_error", "one"}, func(ctx context.Context, keys []string) ([]string, []error, error) {
+		return nil, nil, errors.New("wow")
+	})
+	if assert.Nil(t, err) {
+		if assert.Len(t, errs, 2) {
+			assert.NotNil(t, errs[0])
+			assert.Nil(t, errs[1])
+		}
+	}
+
+	// 结果不够
+	_, _, err = g.MDo(context.TODO(), []string{"not_enough"}, func(ctx context.Context, keys []string) ([]string, []error, error) {
+		return nil, nil, nil
+	})
+	assert.NotNil(t, err)
+
+	// 空
+	results, errs, err = g.MDo(context.TODO(), nil, fDo)
+	assert.Nil(t, err)
+	assert.Nil(t, errs)
+	assert.Nil(t, results)
+}
+
+func TestGroup_ConcurrentlyMDo(t *testing.T) {
+	var big = 5000
+	var g Group[int, string]
+	var flags = make([]uint64, big)
+	var fDo = func(ctx context.Context, keys []int) ([]string, []error, error) {
+		var results []string
+		for _, index := range keys {
+			flag := atomic.AddUint64(&flags[index], 1) // 记下每个index被处理的次数
+			results = append(results, fmt.Sprintf("index: %d, flag: %d", index, flag))
+		}
+		return results, nil, nil
+	}
+
+	rand.Seed(time.Now().UnixNano())
+
+	var wg sync.WaitGroup
+	wg.Add(100)
+	for i := 0; i < 100; i++ {
+		go func() {
+			defer wg.Done()
+
+			all := rand.Perm(big)
+			for len(all) > 0 {
+				n := rand.Intn(10) + 1
+				if n > len(all) {
+					n = len(all)
+				}
+				keys := all[:n]
+				all = all[n:]
+
+				var want []string
+				for _, index := range keys {
+					want = append(want, fmt.Sprintf("index: %d, flag: %d", index, 1))
+				}
+
+				results, errs, err := g.MDo(context.TODO(), keys, fDo)
+				if assert.Nil(t, err) && assert.Nil(t, errs) {
+					assert.Equal(t, want, results)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestGroup_Singleflight(t *testing.T) {
+	g := NewGroup[string, int](WithSingleflight())
+
+	var count int32
+	fDo := func(ctx context.Context) (int, error) {
+		time.Sleep(time.Millisecond * 100)
+		return int(atomic.AddInt32(&count, 1)), nil
+	}
+
+	// 同时进行的请求，合并执行
+	var wg sync.WaitGroup
+	wg.Add(100)
+	for i := 0; i < 100; i++ {
+		go func() {
+			defer wg.Done()
+
+			result, err := g.Do(context.TODO(), "key", fDo)
+			if assert.Nil(t, err) {
+				assert.Equal(t, 1, result)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// 执行完成后，key已经释放，重新执行
+	result, err := g.Do(context.TODO(), "key", fDo)
+	if assert.Nil(t, err) {
+		assert.Equal(t, 2, result)
+	}
+
+	results, _, err := g.MDo(context.TODO(), []string{"key"}, func(ctx context.Context, keys []string) ([]int, []error, error) {
+		return []int{int(atomic.AddInt32(&count, 1))}, nil, nil
+	})
+	if assert.Nil(t, err) {
+		assert.Equal(t, []int{3}, results)
+	}
+	assert.Equal(t, 0, g.calls.len())
+}
+
+func TestGroup_Retention(t *testing.T) {
+	g := NewGroup[string, int](WithRetention(time.Millisecond * 100))
+
+	var count int32
+	fDo := func(ctx context.Context) (int, error) {
+		return int(atomic.AddInt32(&count, 1)), nil
+	}
+
+	result, _ := g.Do(context.TODO(), "key", fDo)
+	assert.Equal(t, 1, result)
+
+	// 保留期内，直接取结果
+	result, _ = g.Do(context.TODO(), "key", fDo)
+	assert.Equal(t, 1, result)
+
+	// 保留期后，重新执行
+	time.Sleep(time.Millisecond * 200)
+	result, _ = g.Do(context.TODO(), "key", fDo)
+	assert.Equal(t, 2, result)
+
+	// 被Delete后，旧的定时器不删除新的哨兵
+	g.Delete("key")
+	result, _ = g.Do(context.TODO(), "key", fDo)
+	assert.Equal(t, 3, result)
+	time.Sleep(time.Millisecond * 50)
+	result, _ = g.Do(context.TODO(), "key", fDo)
+	assert.Equal(t, 3, result)
+}
+
+func TestGroup_DoPanic(t *testing.T) {
+	var g Group[string, int]
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer func() {
+			// 执行f的调用者再次panic
+			assert.Equal(t, "wow", recover())
+		}()
+
+		g.Do(context.TODO(), "key", func(ctx context.Context) (int, error) {
+			close(started)
+			<-release
+			panic("wow")
+		})
+	}()
+
+	<-started
+	wg.Add(10)
+	for i := 0; i < 10; i++ {
+		go func() {
+			defer wg.Done()
+
+			// 等待者得到PanicError
+			_, err := g.Do(context.TODO(), "key", func(ctx context.Context) (int, error) {
+				return 0, nil
+			})
+			if assert.ErrorIs(t, err, ErrProducerPanicked) {
+				var panicErr *PanicError
+				if assert.ErrorAs(t, err, &panicErr) {
+					assert.Equal(t, "wow", panicErr.Value)
+					assert.NotEmpty(t, panicErr.Stack)
+				}
+			}
+		}()
+	}
+	time.Sleep(time.Millisecond * 100) // 等待协程开始等待
+	close(release)
+	wg.Wait()
+
+	// 哨兵已经删除，重新执行
+	result, err := g.Do(context.TODO(), "key", func(ctx context.Context) (int, error) {
+		return 1, nil
+	})
+	if assert.Nil(t, err) {
+		assert.Equal(t, 1, result)
+	}
+}
+
+func TestGroup_DoAbandoned(t *testing.T) {
+	var g Group[string, int]
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	go g.Do(context.TODO(), "key", func(ctx context.Context) (int, error) {
+		close(started)
+		<-release
+		runtime.Goexit()
+		return 0, nil
+	})
+
+	<-started
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		_, err := g.Do(context.TODO(), "key", func(ctx context.Context) (int, error) {
+			return 0, nil
+		})
+		assert.ErrorIs(t, err, ErrAbandoned)
+	}()
+	time.Sleep(time.Millisecond * 100) // 等待协程开始等待
+	close(release)
+	wg.Wait()
+
+	// 哨兵已经删除，重新执行
+	result, err := g.Do(context.TODO(), "key", func(ctx context.Context) (int, error) {
+		return 1, nil
+	})
+	if assert.Nil(t, err) {
+		assert.Equal(t, 1, result)
+	}
+}
+
+func TestGroup_MDoPanic(t *testing.T) {
+	var g Group[string, int]
+
+	assert.PanicsWithValue(t, "wow", func() {
+		g.MDo(context.TODO(), []string{"one", "two"}, func(ctx context.Context, keys []string) ([]int, []error, error) {
+			panic("wow")
+		})
+	})
+
+	// 哨兵已经删除，重新执行
+	results, errs, err := g.MDo(context.TODO(), []string{"one", "two"}, func(ctx context.Context, keys []string) ([]int, []error, error) {
+		return []int{1, 2}, nil, nil
+	})
+	if assert.Nil(t, err) && assert.Nil(t, errs) {
+		assert.Equal(t, []int{1, 2}, results)
+	}
+}
+
+func TestGroup_MDoNotEnoughResults(t *testing.T) {
+	var g Group[string, int]
+
+	_, _, err := g.MDo(context.TODO(), []string{"one", "two"}, func(ctx context.Context, keys []string) ([]int, []error, error) {
+		return []int{1}, nil, nil
+	})
+	assert.Equal(t, errNotEnoughResults, err)
+
+	// 哨兵已经删除，重新执行
+	results, errs, err := g.MDo(context.TODO(), []string{"one", "two"}, func(ctx context.Context, keys []string) ([]int, []error, error) {
+		return []int{1, 2}, nil, nil
+	})
+	if assert.Nil(t, err) && assert.Nil(t, errs) {
+		assert.Equal(t, []int{1, 2}, results)
+	}
+}
+
+func TestGroup_DetachedContext(t *testing.T) {
+	type ctxKey struct{}
+
+	g := NewGroup[string, string](WithDetachedContext(0))
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	f := func(ctx context.Context) (string, error) {
+		close(started)
+		select {
+		case <-release:
+			return ctx.Value(ctxKey{}).(string), nil
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+
+	// 第一个调用者放弃等待，不影响其它调用者
+	ctx1, cancel1 := context.WithCancel(context.WithValue(context.TODO(), ctxKey{}, "value"))
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		_, err := g.Do(ctx1, "key", f)
+		assert.ErrorIs(t, err, context.Canceled)
+	}()
+	<-started
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		result, err := g.Do(context.TODO(), "key", f)
+		if assert.Nil(t, err) {
+			assert.Equal(t, "value", result)
+		}
+	}()
+	time.Sleep(time.Millisecond * 100) // 等待第二个调用者开始等待
+
+	cancel1()
+	time.Sleep(time.Millisecond * 100)
+	close(release)
+	wg.Wait()
+}
+
+func TestGroup_DetachedContextAbandoned(t *testing.T) {
+	g := NewGroup[string, string](WithDetachedContext(0))
+
+	cancelled := make(chan struct{})
+	ctx, cancel := context.WithCancel(context.TODO())
+	var wg sync.WaitGroup
+	wg.Add(3)
+	for i := 0; i < 3; i++ {
+		go func() {
+			defer wg.Done()
+
+			_, err := g.Do(ctx, "key", func(ctx context.Context) (string, error) {
+				<-ctx.Done()
+				close(cancelled)
+				return "", ctx.Err()
+			})
+			assert.ErrorIs(t, err, context.Canceled)
+		}()
+	}
+	time.Sleep(time.Millisecond * 100) // 等待协程开始等待
+
+	// 全部调用者都放弃等待，取消执行
+	cancel()
+	wg.Wait()
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("producer is not cancelled")
+	}
+
+	// 重新执行
+	result, err := g.Do(context.TODO(), "key", func(ctx context.Context) (string, error) {
+		return "ok", nil
+	})
+	if assert.Nil(t, err) {
+		assert.Equal(t, "ok", result)
+	}
+}
+
+func TestGroup_DetachedContextTimeout(t *testing.T) {
+	g := NewGroup[string, string](WithDetachedContext(time.Millisecond * 100))
+
+	_, err := g.Do(context.TODO(), "key", func(ctx context.Context) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// 执行者panic，调用者得到PanicError
+	_, err = g.Do(context.TODO(), "panic", func(ctx context.Context) (string, error) {
+		panic("wow")
+	})
+	assert.ErrorIs(t, err, ErrProducerPanicked)
+}
+
+func TestGroup_DetachedContextMDo(t *testing.T) {
+	g := NewGroup[string, string](WithDetachedContext(0))
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	f := func(ctx context.Context, keys []string) ([]string, []error, error) {
+		close(started)
+		select {
+		case <-release:
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		}
+		var results []string
+		for _, key := range keys {
+			results = append(results, "echo: "+key)
+		}
+		return results, nil, nil
+	}
+
+	ctx1, cancel1 := context.WithCancel(context.TODO())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		_, errs, err := g.MDo(ctx1, []string{"one", "two"}, f)
+		if assert.Nil(t, err) && assert.Len(t, errs, 2) {
+			assert.ErrorIs(t, errs[0], context.Canceled)
+			assert.ErrorIs(t, errs[1], context.Canceled)
+		}
+	}()
+	<-started
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		results, errs, err := g.MDo(context.TODO(), []string{"two"}, f)
+		if assert.Nil(t, err) && assert.Nil(t, errs) {
+			assert.Equal(t, []string{"echo: two"}, results)
+		}
+	}()
+	time.Sleep(time.Millisecond * 100) // 等待第二个调用者开始等待
+
+	cancel1()
+	time.Sleep(time.Millisecond * 100)
+	close(release)
+	wg.Wait()
+}
+
+func TestGroup_DetachedContextMDoStreamAbandoned(t *testing.T) {
+	g := NewGroup[int, string](WithDetachedContext(0))
+
+	canceled := make(chan struct{})
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Millisecond*50)
+	defer cancel()
+	results, errs, err := g.MDoStream(ctx, []int{1, 2}, func(ctx context.Context, keys []int, emit func(index int, value string, err error)) error {
+		emit(0, "one", nil)
+		<-ctx.Done()
+		close(canceled)
+		return ctx.Err()
+	})
+	if assert.Nil(t, err) && assert.Len(t, errs, 2) {
+		assert.Equal(t, "one", results[0])
+		assert.Nil(t, errs[0])
+		assert.ErrorIs(t, errs[1], context.DeadlineExceeded)
+	}
+
+	// 得到结果的key不再计为等待者，全部调用者都放弃等待后，取消执行
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Fatal("producer not canceled")
+	}
+
+	// 已经得到的结果保留
+	result, err := g.Do(context.TODO(), 1, nil)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "one", result)
+	}
+}
+
+func TestGroup_DoChan(t *testing.T) {
+	var g Group[string, string]
+
+	start := make(chan struct{})
+	fDo := func(ctx context.Context) (string, error) {
+		<-start
+		return "ok", nil
+	}
+
+	ch1 := g.DoChan(context.TODO(), "key", fDo)
+	time.Sleep(time.Millisecond * 10)
+	ch2 := g.DoChan(context.TODO(), "key", fDo)
+	time.Sleep(time.Millisecond * 10)
+	close(start)
+
+	for _, ch := range []<-chan Result[string]{ch1, ch2} {
+		result := <-ch
+		if assert.Nil(t, result.Err) {
+			assert.Equal(t, "ok", result.Val)
+			assert.True(t, result.Shared)
+		}
+	}
+
+	// 只有一个调用者
+	result := <-g.DoChan(context.TODO(), "other", func(ctx context.Context) (string, error) {
+		return "other", nil
+	})
+	if assert.Nil(t, result.Err) {
+		assert.Equal(t, "other", result.Val)
+		assert.False(t, result.Shared)
+	}
+
+	// panic
+	result = <-g.DoChan(context.TODO(), "panic", func(ctx context.Context) (string, error) {
+		panic("wow")
+	})
+	var panicErr *PanicError
+	if assert.ErrorAs(t, result.Err, &panicErr) {
+		assert.Equal(t, "wow", panicErr.Value)
+	}
+}
+
+func TestGroup_MDoChan(t *testing.T) {
+	var g Group[int, int]
+
+	fMDo := func(ctx context.Context, keys []int) ([]int, []error, error) {
+		results := make([]int, len(keys))
+		for i, key := range keys {
+			results[i] = key * 10
+		}
+		return results, nil, nil
+	}
+
+	result := <-g.MDoChan(context.TODO(), []int{1, 2}, fMDo)
+	if assert.Nil(t, result.Err) && assert.Nil(t, result.Errs) {
+		assert.Equal(t, []int{10, 20}, result.Vals)
+		assert.False(t, result.Shared)
+	}
+
+	// 2已经执行过
+	result = <-g.MDoChan(context.TODO(), []int{2, 3}, fMDo)
+	if assert.Nil(t, result.Err) && assert.Nil(t, result.Errs) {
+		assert.Equal(t, []int{20, 30}, result.Vals)
+		assert.True(t, result.Shared)
+	}
+
+	// 同一次调用中重复的key，不计为共享
+	var executedKeys []int
+	result = <-g.MDoChan(context.TODO(), []int{4, 4, 5}, func(ctx context.Context, keys []int) ([]int, []error, error) {
+		executedKeys = keys
+		return fMDo(ctx, keys)
+	})
+	if assert.Nil(t, result.Err) && assert.Nil(t, result.Errs) {
+		assert.Equal(t, []int{40, 40, 50}, result.Vals)
+		assert.False(t, result.Shared)
+	}
+	assert.Equal(t, []int{4, 5}, executedKeys)
+	assert.Equal(t, int64(1), g.Stats().CoalescedWaits)
+}
+
+func TestGroup_Forget(t *testing.T) {
+	var g Group[string, int]
+
+	var count int32
+	start := make(chan struct{})
+	fDo := func(ctx context.Context) (int, error) {
+		n := atomic.AddInt32(&count, 1)
+		if n == 1 {
+			<-start
+		}
+		return int(n), nil
+	}
+
+	ch1 := g.DoChan(context.TODO(), "key", fDo)
+	time.Sleep(time.Millisecond * 10)
+	ch2 := g.DoChan(context.TODO(), "key", fDo)
+	time.Sleep(time.Millisecond * 10)
+
+	// 执行中，Forget后新的调用者重新执行
+	g.Forget("key")
+	result, err := g.Do(context.TODO(), "key", fDo)
+	if assert.Nil(t, err) {
+		assert.Equal(t, 2, result)
+	}
+
+	// 已经在等待的调用者，仍得到原来的结果
+	close(start)
+	for _, ch := range []<-chan Result[int]{ch1, ch2} {
+		result := <-ch
+		if assert.Nil(t, result.Err) {
+			assert.Equal(t, 1, result.Val)
+		}
+	}
+
+	// 原来的执行完成后，不覆盖新的结果
+	result, err = g.Do(context.TODO(), "key", fDo)
+	if assert.Nil(t, err) {
+		assert.Equal(t, 2, result)
+	}
+}
+
+func TestGroup_MDoStream(t *testing.T) {
+	var g Group[int, string]
+
+	firstEmitted := make(chan struct{})
+	finish := make(chan struct{})
+	ch := make(chan MResult[string], 1)
+	go func() {
+		results, errs, err := g.MDoStream(context.TODO(), []int{1, 2, 3}, func(ctx context.Context, keys []int, emit func(index int, value string, err error)) error {
+			// 第一页
+			emit(0, "one", nil)
+			close(firstEmitted)
+
+			// 第二页
+			<-finish
+			emit(1, "", errors.New("test"))
+			emit(0, "again", nil) // 重复提交，忽略
+			return nil            // 3没有提交
+		})
+		ch <- MResult[string]{Vals: results, Errs: errs, Err: err}
+	}()
+
+	// 批量处理还没完成，已经可以得到1的结果
+	<-firstEmitted
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Second)
+	defer cancel()
+	result, err := g.Do(ctx, 1, nil)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "one", result)
+	}
+
+	close(finish)
+	mresult := <-ch
+	if assert.Nil(t, mresult.Err) && assert.Len(t, mresult.Errs, 3) {
+		assert.Equal(t, []string{"one", "", ""}, mresult.Vals)
+		assert.Nil(t, mresult.Errs[0])
+		assert.EqualError(t, mresult.Errs[1], "test")
+		assert.Equal(t, errNotEnoughResults, mresult.Errs[2])
+	}
+
+	// 出错
+	_, errs, err := g.MDoStream(context.TODO(), []int{4, 5}, func(ctx context.Context, keys []int, emit func(index int, value string, err error)) error {
+		emit(1, "five", nil)
+		return errors.New("partial")
+	})
+	if assert.Nil(t, err) && assert.Len(t, errs, 2) {
+		assert.EqualError(t, errs[0], "partial")
+		assert.Nil(t, errs[1])
+	}
+
+	// panic
+	assert.PanicsWithValue(t, "wow", func() {
+		g.MDoStream(context.TODO(), []int{6, 7}, func(ctx context.Context, keys []int, emit func(index int, value string, err error)) error {
+			emit(0, "six", nil)
+			panic("wow")
+		})
+	})
+	result, err = g.Do(context.TODO(), 6, nil)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "six", result)
+	}
+	// 7已经删除，重新执行
+	result, err = g.Do(context.TODO(), 7, func(ctx context.Context) (string, error) {
+		return "seven", nil
+	})
+	if assert.Nil(t, err) {
+		assert.Equal(t, "seven", result)
+	}
+}
+
+func TestGroup_MDoMap(t *testing.T) {
+	var g Group[int, string]
+
+	results, errs, err := g.MDoMap(context.TODO(), []int{1, 2, 3}, func(ctx context.Context, keys []int) (map[int]string, map[int]error, error) {
+		// 顺序和keys无关
+		return map[int]string{3: "three", 1: "one"}, map[int]error{}, nil
+	})
+	if assert.Nil(t, err) && assert.Len(t, errs, 3) {
+		assert.Equal(t, []string{"one", "", "three"}, results)
+		assert.Nil(t, errs[0])
+		assert.ErrorIs(t, errs[1], ErrNotFound)
+		var notFoundErr *NotFoundError
+		if assert.ErrorAs(t, errs[1], &notFoundErr) {
+			assert.Equal(t, 2, notFoundErr.Key)
+		}
+		assert.Nil(t, errs[2])
+	}
+
+	// 单个key的错误
+	_, errs, err = g.MDoMap(context.TODO(), []int{4}, func(ctx context.Context, keys []int) (map[int]string, map[int]error, error) {
+		return nil, map[int]error{4: errors.New("test")}, nil
+	})
+	if assert.Nil(t, err) && assert.Len(t, errs, 1) {
+		assert.EqualError(t, errs[0], "test")
+	}
+}
+
+func TestGroup_WaitTimeout(t *testing.T) {
+	g := NewGroup[string, int](WithWaitTimeout(time.Millisecond * 20))
+
+	finish := make(chan struct{})
+	defer close(finish)
+	started := make(chan struct{})
+	go g.Do(context.TODO(), "key", func(ctx context.Context) (int, error) {
+		close(started)
+		<-finish
+		return 1, nil
+	})
+	<-started
+
+	start := time.Now()
+	_, err := g.Do(context.TODO(), "key", nil)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestGroup_WaitOrDo(t *testing.T) {
+	g := NewGroup[string, string](WithWaitOrDo(time.Millisecond * 20))
+
+	finish := make(chan struct{})
+	started := make(chan struct{})
+	leaderCh := g.DoChan(context.TODO(), "key", func(ctx context.Context) (string, error) {
+		close(started)
+		<-finish
+		return "leader", nil
+	})
+	<-started
+
+	// 等待一段时间后，自己执行
+	result, err := g.Do(context.TODO(), "key", func(ctx context.Context) (string, error) {
+		return "hedged", nil
+	})
+	if assert.Nil(t, err) {
+		assert.Equal(t, "hedged", result)
+	}
+
+	// 执行者的结果先到
+	waiterCh := g.DoChan(context.TODO(), "key", func(ctx context.Context) (string, error) {
+		<-ctx.Done() // 被取消
+		return "hedged", ctx.Err()
+	})
+	time.Sleep(time.Millisecond * 40)
+	close(finish)
+	waiterResult := <-waiterCh
+	if assert.Nil(t, waiterResult.Err) {
+		assert.Equal(t, "leader", waiterResult.Val)
+	}
+	leaderResult := <-leaderCh
+	if assert.Nil(t, leaderResult.Err) {
+		assert.Equal(t, "leader", leaderResult.Val)
+	}
+
+	// 自己执行的结果没有保存
+	result, err = g.Do(context.TODO(), "key", nil)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "leader", result)
+	}
+
+	// 自己执行计入统计
+	assert.Eventually(t, func() bool {
+		return g.Stats() == Stats{Executions: 3, CoalescedWaits: 3, Errors: 1, Hedges: 2}
+	}, time.Second, time.Millisecond*10)
+}
@@ -3,7 +3,6 @@ package sentinel
 import (
 	"context"
 	"errors"
-	"fmt"
 	"reflect"
 	"sync"
 )
@@ -14,190 +13,338 @@ type DoFunc func(ctx context.Context, destPtr interface{}, args interface{}) err
 // MDofunc 哨兵批量处理时执行的函数。
 type MDofunc func(ctx context.Context, destSlicePtr interface{}, argsSlice interface{}) ([]error, error)
 
-// SentinelGroup 哨兵组。
+// MDoMapFunc 哨兵按key返回结果的批量处理时执行的函数。
+// destMapPtr是map[K]T的指针，T是destSlicePtr指向的切片的元素类型；map已经创建，直接写入结果。
+// keys是需要执行的key，argsSlice是对应的参数，顺序同keys。
+// 返回key到错误的map。结果和错误中都没有的key，得到*NotFoundError。
+type MDoMapFunc[K comparable] func(ctx context.Context, destMapPtr interface{}, keys []K, argsSlice interface{}) (map[K]error, error)
+
+// MDoStreamFunc 哨兵流式批量处理时执行的函数。
+// 每得到一个参数的结果，调用emit提交。其它同GroupMDoStreamFunc。
+type MDoStreamFunc func(ctx context.Context, argsSlice interface{}, emit func(index int, value interface{}, err error)) error
+
+// ChanResult SentinelGroupOf.DoChan和SentinelGroupOf.MDoChan的结果。
+type ChanResult struct {
+	// Errs MDoChan的各个位置上的错误。
+	Errs []error
+	Err  error
+
+	// Shared 是否有结果还给了其它调用者。
+	Shared bool
+}
+
+// SentinelGroup key为字符串的哨兵组。
+// 零值可用。默认在调用Delete前一直保留结果。
 type SentinelGroup struct {
-	sentinelMap sync.Map
+	SentinelGroupOf[string]
+}
+
+// NewSentinelGroup 新建哨兵组。
+func NewSentinelGroup(opts ...Option) *SentinelGroup {
+	sg := &SentinelGroup{}
+	sg.group.setOptions(newOptions(opts...))
+	return sg
+}
+
+// SentinelGroupOf key为任意可比较类型的哨兵组。例如多个字段组成的结构体。
+// 零值可用。默认在调用Delete前一直保留结果。
+type SentinelGroupOf[K comparable] struct {
+	group Group[K, interface{}]
 }
 
-// Do key删除前，不重复执行key相同的逻辑。
-// destPtr指向的内容是共享的，不可修改。
+// NewSentinelGroupOf 新建key为K类型的哨兵组。
+func NewSentinelGroupOf[K comparable](opts ...Option) *SentinelGroupOf[K] {
+	sg := &SentinelGroupOf[K]{}
+	sg.group.setOptions(newOptions(opts...))
+	return sg
+}
+
+// Do key删除（或者按选项释放）前，不重复执行key相同的逻辑。
+// destPtr指向的内容是共享的，不可修改；除非设置了WithCloner或者WithCloneMethod。
+// 如果f panic，执行f的调用者再次panic，等待者得到*PanicError，key被删除，下次重新执行。
 // 修改自：https://github.com/wencan/cachex/blob/master/cachex.go
-func (sg *SentinelGroup) Do(ctx context.Context, destPtr interface{}, key string, args interface{}, f DoFunc) error {
-	sentinel := NewSentinel()
-	defer sentinel.Close()
-	actual, loaded := sg.sentinelMap.LoadOrStore(key, sentinel) // 这里性能不是很好。尤其是写多时
-	if loaded {
-		// 由其它过程执行
-		// 这里等待其它逻辑的执行结果
-		waitSentinel := actual.(*Sentinel)
-		err := waitSentinel.Wait(ctx, destPtr)
-		return err
-	} else {
-		// do it
+func (sg *SentinelGroupOf[K]) Do(ctx context.Context, destPtr interface{}, key K, args interface{}, f DoFunc) error {
+	_, err := sg.do(ctx, destPtr, key, args, f)
+	return err
+}
+
+// DoChan 同Do，但不阻塞，返回接收结果的通道。通道只接收一个结果。
+// 接收到结果后，才可以读取destPtr。如果f panic，结果的Err为*PanicError。
+func (sg *SentinelGroupOf[K]) DoChan(ctx context.Context, destPtr interface{}, key K, args interface{}, f DoFunc) <-chan ChanResult {
+	ch := make(chan ChanResult, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				ch <- ChanResult{Err: newPanicError(r)}
+			}
+		}()
+
+		shared, err := sg.do(ctx, destPtr, key, args, f)
+		ch <- ChanResult{Err: err, Shared: shared}
+	}()
+	return ch
+}
+
+func (sg *SentinelGroupOf[K]) do(ctx context.Context, destPtr interface{}, key K, args interface{}, f DoFunc) (shared bool, err error) {
+	destValue := reflect.ValueOf(destPtr).Elem()
+	doFunc := func(ctx context.Context) (interface{}, error) {
+		if sg.group.opts.detached || sg.group.opts.hedgeDelay > 0 {
+			// 独立执行，或者等待者自己执行时，调用者可能已经返回，不能再写destPtr
+			valuePtr := reflect.New(destValue.Type())
+			err := f(ctx, valuePtr.Interface(), args)
+			return valuePtr.Elem().Interface(), err
+		}
+
 		err := f(ctx, destPtr, args)
-		dest := reflect.ValueOf(destPtr).Elem().Interface()
-		sentinel.Done(dest, err)
-		return err
+		return destValue.Interface(), err
+	}
+	result, shared, err := sg.group.doCall(ctx, key, distributedDo(sg.group.opts.distributed, key, doFunc, sg.decodeAs(destValue.Type())), doFunc)
+	if err != nil {
+		return shared, err
 	}
+
+	if result != nil {
+		destValue.Set(reflect.ValueOf(result))
+	}
+	return shared, nil
 }
 
-// MDO 处理一批数据。key删除前，不重复执行key相同的逻辑。
+// MDO 处理一批数据。key删除（或者按选项释放）前，不重复执行key相同的逻辑。
 // destSlicePtr 是获取结果的切片的指针。
 // argsSlice 是给函数f的参数，顺序和长度等于keys的顺序和长度。
 // []error表示各个下标位置上的错误。如果没有错误，可以为nil。
 // 函数f返回destSlicePtr顺序同keys/argsSlice顺序，destSlicePtr中缺失项必须在返回[]error的相同下标位置有error。
 // 总是尽可能返回[]error，表示各个位置上的错误；除非无法组成合格的[]error，才会返回error。
-// destSlicePtr指向的切片内各元素的数据是共享的，不可修改。
-func (sg *SentinelGroup) MDo(ctx context.Context, destSlicePtr interface{}, keys []string, argsSlice interface{}, f MDofunc) ([]error, error) {
+// destSlicePtr指向的切片内各元素的数据是共享的，不可修改；除非设置了WithCloner或者WithCloneMethod。
+func (sg *SentinelGroupOf[K]) MDo(ctx context.Context, destSlicePtr interface{}, keys []K, argsSlice interface{}, f MDofunc) ([]error, error) {
+	errs, _, err := sg.mdo(ctx, destSlicePtr, keys, argsSlice, f)
+	return errs, err
+}
+
+// MDoChan 同MDo，但不阻塞，返回接收结果的通道。通道只接收一个结果。
+// 接收到结果后，才可以读取destSlicePtr。如果f panic，结果的Err为*PanicError。
+func (sg *SentinelGroupOf[K]) MDoChan(ctx context.Context, destSlicePtr interface{}, keys []K, argsSlice interface{}, f MDofunc) <-chan ChanResult {
+	ch := make(chan ChanResult, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				ch <- ChanResult{Err: newPanicError(r)}
+			}
+		}()
+
+		errs, shared, err := sg.mdo(ctx, destSlicePtr, keys, argsSlice, f)
+		ch <- ChanResult{Errs: errs, Err: err, Shared: shared}
+	}()
+	return ch
+}
+
+func (sg *SentinelGroupOf[K]) mdo(ctx context.Context, destSlicePtr interface{}, keys []K, argsSlice interface{}, f MDofunc) (errs []error, shared bool, err error) {
+	destSliceType := reflect.TypeOf(destSlicePtr).Elem()
+	return sg.mdoBatch(ctx, destSlicePtr, keys, argsSlice, func(doArgs func(doKeys []K) (interface{}, error)) batchFunc[K, interface{}] {
+		return sg.group.batch(distributedMDo(sg.group.opts.distributed, func(ctx context.Context, doKeys []K) ([]interface{}, []error, error) {
+			// 参数
+			actualArgsSlice, err := doArgs(doKeys)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			// 执行
+			actualDestSlicePtr := reflect.New(destSliceType)
+			errs, err := f(ctx, actualDestSlicePtr.Interface(), actualArgsSlice)
+			if err != nil {
+				return nil, nil, err
+			}
+			actualDestSlice := reflect.Indirect(actualDestSlicePtr)
+
+			// 取结果
+			values := make([]interface{}, len(doKeys))
+			var actualDestCount int
+			for idx := range doKeys {
+				if len(errs) > idx && errs[idx] != nil { // 允许省去后面的nil
+					continue
+				}
+				if actualDestSlice.Len() <= actualDestCount {
+					return nil, nil, &fatalError{err: errNotEnoughResults}
+				}
+				values[idx] = actualDestSlice.Index(actualDestCount).Interface()
+				actualDestCount++
+			}
+			return values, errs, nil
+		}, sg.decodeAs(destSliceType.Elem())))
+	})
+}
+
+// mdoBatch 批量处理的公共部分。
+// newBatch创建执行需要执行的key的batchFunc；doArgs返回需要执行的key对应的参数切片。
+func (sg *SentinelGroupOf[K]) mdoBatch(ctx context.Context, destSlicePtr interface{}, keys []K, argsSlice interface{}, newBatch func(doArgs func(doKeys []K) (interface{}, error)) batchFunc[K, interface{}]) (errs []error, shared bool, err error) {
 	if len(keys) == 0 {
-		return nil, nil
+		return nil, false, nil
 	}
 
-	var waitSentinelMap = make(map[int]*Sentinel)
-	var doSentinelMap = make(map[int]*Sentinel)
+	destSliceValue := reflect.ValueOf(destSlicePtr).Elem()
+	argsSliceValue := reflect.ValueOf(argsSlice)
+	for argsSliceValue.Kind() == reflect.Ptr {
+		argsSliceValue = reflect.Indirect(argsSliceValue)
+	}
 
-	var sentinal *Sentinel
+	firstIndexes := make(map[K]int, len(keys)) // key -> 在keys中首次出现的下标
 	for index, key := range keys {
-		if sentinal == nil { // 如果已经使用，创建一个新的
-			sentinal = NewSentinel()
+		if _, ok := firstIndexes[key]; !ok {
+			firstIndexes[key] = index
 		}
-		actual, loaded := sg.sentinelMap.LoadOrStore(key, sentinal) // 这里性能不是很好。尤其是写多时
-		if loaded {
-			// 由其它过程执行
-			// 这里等待其它逻辑的执行结果
-			waitSentinel := actual.(*Sentinel)
-			waitSentinelMap[index] = waitSentinel
-		} else {
-			// 需要下面执行的
-			doSentinelMap[index] = sentinal
-			sentinal = nil // 已经使用，下次需要重新创建
-		}
-	}
-	if sentinal != nil { // 多余的
-		sentinal.Close()
 	}
 
-	// 清理
-	defer func() {
-		for _, sentinel := range doSentinelMap {
-			sentinel.Close() // 如果panic，通知wait的逻辑返回
+	var mu sync.Mutex
+	executed := make(map[K]bool) // 本次调用执行的key。执行时可能分多次取参数
+	doArgs := func(doKeys []K) (interface{}, error) {
+		mu.Lock()
+		for _, key := range doKeys {
+			executed[key] = true
 		}
-		for _, sentinel := range waitSentinelMap {
-			sentinel.Close()
+		mu.Unlock()
+
+		if len(keys) != argsSliceValue.Len() { // 参数检查
+			return nil, &fatalError{err: errors.New("wrong argsSlice")}
 		}
-	}()
+		actualArgsSliceValue := reflect.MakeSlice(argsSliceValue.Type(), 0, len(doKeys))
+		for _, key := range doKeys { // 保证按照原顺序
+			actualArgsSliceValue = reflect.Append(actualArgsSliceValue, argsSliceValue.Index(firstIndexes[key]))
+		}
+		return actualArgsSliceValue.Interface(), nil
+	}
 
-	destSliceValue := reflect.ValueOf(destSlicePtr).Elem()
-	destElemResultMap := make(map[int]reflect.Value) // 每个下标位置上的结果
-	destElemErrorMap := make(map[int]error)          // 每个下标位置上的错误
-
-	// 准备执行函数f
-	if len(doSentinelMap) > 0 {
-		// 参数
-		argsSliceValue := reflect.ValueOf(argsSlice)
-		for argsSliceValue.Kind() == reflect.Ptr {
-			argsSliceValue = reflect.Indirect(argsSliceValue)
+	values, errs, shared, err := sg.group.mdoCall(ctx, keys, newBatch(doArgs))
+	if err != nil {
+		return nil, false, err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	for index, err := range errs {
+		var fatalErr *fatalError
+		if !errors.As(err, &fatalErr) {
+			continue
 		}
-		if len(keys) != argsSliceValue.Len() { // 参数检查
-			return nil, errors.New("wrong argsSlice")
+		if executed[keys[index]] {
+			return nil, false, fatalErr.err
 		}
-		actualArgsSliceValue := reflect.MakeSlice(argsSliceValue.Type(), 0, len(doSentinelMap))
-		indexMap := make(map[int]int, len(doSentinelMap)) // 外部索引 -> 执行函数参数序列中的索引
-		var argCount int
-		for index := 0; index < argsSliceValue.Len(); index++ { // 保证按照原顺序
-			_, ok := doSentinelMap[index]
-			if ok {
-				actualArgsSliceValue = reflect.Append(actualArgsSliceValue, argsSliceValue.Index(index))
-				indexMap[index] = argCount
-				argCount++
-			}
+		// 其它调用者违反调用约定，只是这个位置上的错误
+		errs[index] = fatalErr.err
+	}
+
+	// 准备返回的结果
+	elemType := destSliceValue.Type().Elem()
+	for index, value := range values {
+		if errs != nil && errs[index] != nil {
+			continue
 		}
-		// 执行
-		actualDestSlicePtr := reflect.New(destSliceValue.Type())
-		errs, err := f(ctx, actualDestSlicePtr.Interface(), actualArgsSliceValue.Interface())
-		if err != nil {
-			// 每个位置上都是错误
-			errs = make([]error, 0, len(doSentinelMap))
-			for idx := 0; idx < actualArgsSliceValue.Len(); idx++ {
-				errs = append(errs, err)
-			}
+		if value == nil {
+			destSliceValue.Set(reflect.Append(destSliceValue, reflect.Zero(elemType)))
+		} else {
+			destSliceValue.Set(reflect.Append(destSliceValue, reflect.ValueOf(value)))
 		}
-		actualDestSlice := reflect.Indirect(actualDestSlicePtr)
-
-		// 取结果
-		var actualDestCount int
-		for index := range keys {
-			sentinel, ok := doSentinelMap[index]
-			if !ok {
-				continue
+	}
+	return errs, shared, nil
+}
+
+// MDoMap 同MDo，但函数f按key返回结果，适用于WHERE id IN (...)这类查询。
+// f返回的结果中没有的key，得到*NotFoundError。
+func (sg *SentinelGroupOf[K]) MDoMap(ctx context.Context, destSlicePtr interface{}, keys []K, argsSlice interface{}, f MDoMapFunc[K]) ([]error, error) {
+	var zeroKey K
+	keyType := reflect.TypeOf(&zeroKey).Elem()
+	mapType := reflect.MapOf(keyType, reflect.TypeOf(destSlicePtr).Elem().Elem())
+
+	errs, _, err := sg.mdoBatch(ctx, destSlicePtr, keys, argsSlice, func(doArgs func(doKeys []K) (interface{}, error)) batchFunc[K, interface{}] {
+		return sg.group.batch(distributedMDo(sg.group.opts.distributed, func(ctx context.Context, doKeys []K) ([]interface{}, []error, error) {
+			// 参数
+			actualArgsSlice, err := doArgs(doKeys)
+			if err != nil {
+				return nil, nil, err
 			}
 
-			var result interface{}
-			var err error
-			doIdx, ok := indexMap[index] // 在执行函数参数序列中的下标
-			if !ok {
-				panic("wrong index")
+			// 执行
+			destMapPtr := reflect.New(mapType)
+			destMapPtr.Elem().Set(reflect.MakeMap(mapType))
+			errMap, err := f(ctx, destMapPtr.Interface(), doKeys, actualArgsSlice)
+			if err != nil {
+				return nil, nil, err
 			}
-			if len(errs) > doIdx { // 允许省去后面的nil
-				err = errs[doIdx]
-				if err != nil {
-					destElemErrorMap[index] = err
+			destMap := destMapPtr.Elem()
+
+			// 取结果
+			values := make([]interface{}, len(doKeys))
+			errs := make([]error, len(doKeys))
+			for idx, key := range doKeys {
+				if err := errMap[key]; err != nil {
+					errs[idx] = err
+					continue
 				}
-			}
-			if err == nil {
-				if actualDestSlice.Len() <= actualDestCount {
-					return nil, errors.New("not enough results")
+				value := destMap.MapIndex(reflect.ValueOf(&key).Elem())
+				if !value.IsValid() {
+					errs[idx] = &NotFoundError{Key: key}
+					continue
 				}
-				value := actualDestSlice.Index(actualDestCount)
-				result = value.Interface()
-				destElemResultMap[index] = value
-				actualDestCount++
+				values[idx] = value.Interface()
 			}
+			return values, errs, nil
+		}, sg.decodeAs(mapType.Elem())))
+	})
+	return errs, err
+}
 
-			// 通知其它在等待的过程
-			sentinel.Done(result, err)
-		}
-	}
-
-	//  等待其它过程完成
-	if len(waitSentinelMap) > 0 {
-		for index, sentinal := range waitSentinelMap {
-			valuePtr := reflect.New(destSliceValue.Type().Elem())
-			err := sentinal.Wait(ctx, valuePtr.Interface())
+// MDoStream 同MDo，但使用流式批量处理的函数f。
+// f每提交一个key的结果，立即通知等待这个key的其它调用者，不等待整批处理完成。
+// emit的index是参数在f的argsSlice中的下标；value的类型是destSlicePtr指向的切片的元素类型。
+func (sg *SentinelGroupOf[K]) MDoStream(ctx context.Context, destSlicePtr interface{}, keys []K, argsSlice interface{}, f MDoStreamFunc) ([]error, error) {
+	errs, _, err := sg.mdoBatch(ctx, destSlicePtr, keys, argsSlice, func(doArgs func(doKeys []K) (interface{}, error)) batchFunc[K, interface{}] {
+		return sg.group.streamBatch(func(ctx context.Context, doKeys []K, emit func(index int, value interface{}, err error)) error {
+			actualArgsSlice, err := doArgs(doKeys)
 			if err != nil {
-				destElemErrorMap[index] = err
-			} else {
-				destElemResultMap[index] = valuePtr.Elem()
+				return err
 			}
-		}
-	}
-
-	// 准备返回的结果
-	var errs []error
-	for i := 0; i < len(keys); i++ {
-		elemValue, ok := destElemResultMap[i]
-		elemErr := destElemErrorMap[i]
+			return f(ctx, actualArgsSlice, emit)
+		})
+	})
+	return errs, err
+}
 
-		if elemErr != nil {
-			errs = append(errs, elemErr)
-		} else {
-			if !ok {
-				return nil, fmt.Errorf("not found result by key %s", keys[i])
-			}
-			destSliceValue.Set(reflect.Append(destSliceValue, elemValue))
-			errs = append(errs, nil)
-		}
+// decodeAs 返回跨进程协调时，将结果解码为typ类型的函数。
+func (sg *SentinelGroupOf[K]) decodeAs(typ reflect.Type) func(data []byte) (interface{}, error) {
+	d := sg.group.opts.distributed
+	if d == nil {
+		return nil
 	}
-
-	if len(destElemErrorMap) > 0 {
-		return errs, nil
+	return func(data []byte) (interface{}, error) {
+		valuePtr := reflect.New(typ)
+		err := d.codec.Unmarshal(data, valuePtr.Interface())
+		return valuePtr.Elem().Interface(), err
 	}
-	return nil, nil
+}
+
+// Stats 返回统计。
+func (sg *SentinelGroupOf[K]) Stats() Stats {
+	return sg.group.Stats()
+}
+
+// Forget 删除key对应的哨兵。
+// 正在执行的逻辑继续执行，已经在等待的调用者仍得到它的结果；之后的调用者重新执行该key的逻辑。
+func (sg *SentinelGroupOf[K]) Forget(key K) {
+	sg.group.Forget(key)
 }
 
 // Delete 删除key对应的哨兵。下次需要重新执行该key的逻辑。
-func (sg *SentinelGroup) Delete(keys ...string) {
-	for _, key := range keys {
-		sg.sentinelMap.Delete(key)
-	}
+func (sg *SentinelGroupOf[K]) Delete(keys ...K) {
+	sg.group.Delete(keys...)
+}
+
+// fatalError 无法组成合格的[]error的错误。
+type fatalError struct {
+	err error
+}
+
+func (e *fatalError) Error() string {
+	return e.err.Error()
+}
+
+func (e *fatalError) Unwrap() error {
+	return e.err
 }
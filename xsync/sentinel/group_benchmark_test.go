@@ -0,0 +1,167 @@
+package sentinel
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// BenchmarkSyncMap_LoadOrStore 原来的哨兵表使用的sync.Map，作为基准。
+func BenchmarkSyncMap_LoadOrStore(b *testing.B) {
+	var m sync.Map
+	var seq int64
+
+	b.ResetTimer()
+	b.RunParallel(func(p *testing.PB) {
+		for p.Next() {
+			key := strconv.FormatInt(atomic.AddInt64(&seq, 1), 10)
+			m.LoadOrStore(key, &call[int]{})
+		}
+	})
+}
+
+// BenchmarkSyncMap_LoadOrStoreDelete 执行完成后删除key，同WithSingleflight。
+func BenchmarkSyncMap_LoadOrStoreDelete(b *testing.B) {
+	var m sync.Map
+	var seq int64
+
+	b.ResetTimer()
+	b.RunParallel(func(p *testing.PB) {
+		for p.Next() {
+			key := strconv.FormatInt(atomic.AddInt64(&seq, 1), 10)
+			m.LoadOrStore(key, &call[int]{})
+			m.Delete(key)
+		}
+	})
+}
+
+// singleMutexMap 只有一把锁的哨兵表，作为对照。
+type singleMutexMap struct {
+	mu sync.Mutex
+	m  map[string]*call[int]
+}
+
+func (sm *singleMutexMap) loadOrStore(key string, c *call[int]) (*call[int], bool) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	if actual, ok := sm.m[key]; ok {
+		return actual, true
+	}
+	sm.m[key] = c
+	return c, false
+}
+
+func BenchmarkSingleMutexMap_LoadOrStore(b *testing.B) {
+	sm := &singleMutexMap{m: make(map[string]*call[int])}
+	var seq int64
+
+	b.ResetTimer()
+	b.RunParallel(func(p *testing.PB) {
+		for p.Next() {
+			key := strconv.FormatInt(atomic.AddInt64(&seq, 1), 10)
+			sm.loadOrStore(key, &call[int]{})
+		}
+	})
+}
+
+func BenchmarkCallMap_LoadOrStore(b *testing.B) {
+	var cm callMap[string, int]
+	var seq int64
+
+	b.ResetTimer()
+	b.RunParallel(func(p *testing.PB) {
+		for p.Next() {
+			key := strconv.FormatInt(atomic.AddInt64(&seq, 1), 10)
+			cm.loadOrStore(key, &call[int]{})
+		}
+	})
+}
+
+func BenchmarkCallMap_LoadOrStoreDelete(b *testing.B) {
+	var cm callMap[string, int]
+	var seq int64
+
+	b.ResetTimer()
+	b.RunParallel(func(p *testing.PB) {
+		for p.Next() {
+			key := strconv.FormatInt(atomic.AddInt64(&seq, 1), 10)
+			c := &call[int]{}
+			cm.loadOrStore(key, c)
+			cm.remove(key, c)
+		}
+	})
+}
+
+func BenchmarkGroup_DoUniqueKeys(b *testing.B) {
+	g := NewGroup[string, int](WithSingleflight())
+	fDo := func(ctx context.Context) (int, error) {
+		return 1, nil
+	}
+	var seq int64
+
+	b.ResetTimer()
+	b.RunParallel(func(p *testing.PB) {
+		for p.Next() {
+			key := strconv.FormatInt(atomic.AddInt64(&seq, 1), 10)
+			g.Do(context.TODO(), key, fDo)
+		}
+	})
+}
+
+func BenchmarkGroup_MDoUniqueKeys(b *testing.B) {
+	g := NewGroup[string, int](WithSingleflight())
+	fMDo := func(ctx context.Context, keys []string) ([]int, []error, error) {
+		return make([]int, len(keys)), nil, nil
+	}
+	var seq int64
+
+	b.ResetTimer()
+	b.RunParallel(func(p *testing.PB) {
+		keys := make([]string, 16)
+		for p.Next() {
+			for i := range keys {
+				keys[i] = strconv.FormatInt(atomic.AddInt64(&seq, 1), 10)
+			}
+			g.MDo(context.TODO(), keys, fMDo)
+		}
+	})
+}
+
+func BenchmarkSentinelGroup_DoUniqueKeys(b *testing.B) {
+	sg := NewSentinelGroup(WithSingleflight())
+	fDo := func(ctx context.Context, destPtr, args interface{}) error {
+		*(destPtr.(*int)) = 1
+		return nil
+	}
+	var seq int64
+
+	b.ResetTimer()
+	b.RunParallel(func(p *testing.PB) {
+		var resp int
+		for p.Next() {
+			key := strconv.FormatInt(atomic.AddInt64(&seq, 1), 10)
+			sg.Do(context.TODO(), &resp, key, nil, fDo)
+		}
+	})
+}
+
+func BenchmarkCallMap_LoadOrStoreStructKeys(b *testing.B) {
+	type structKey struct {
+		Tenant string
+		ID     int64
+	}
+	var cm callMap[structKey, int]
+	var seq int64
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(p *testing.PB) {
+		for p.Next() {
+			key := structKey{Tenant: "tenant", ID: atomic.AddInt64(&seq, 1)}
+			cm.loadOrStore(key, &call[int]{})
+		}
+	})
+}
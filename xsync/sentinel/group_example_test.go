@@ -111,3 +111,32 @@ func ExampleSentinelGroup_partialFailure() {
 	// echo: three
 	// partial failure: skip
 }
+
+func ExampleSentinelGroupOf_Do() {
+	type userKey struct {
+		Tenant string
+		ID     int
+	}
+	var sg SentinelGroupOf[userKey]
+
+	f := func(ctx context.Context, destPtr interface{}, args interface{}) error {
+		resp := destPtr.(*string)
+		key := args.(userKey)
+
+		*resp = fmt.Sprintf("user %d of %s", key.ID, key.Tenant)
+
+		return nil
+	}
+
+	var key = userKey{Tenant: "acme", ID: 1}
+	var resp string
+	err := sg.Do(context.TODO(), &resp, key, key, f)
+	defer sg.Delete(key)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	fmt.Println(resp)
+	// Output: user 1 of acme
+}
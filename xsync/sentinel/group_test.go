@@ -595,3 +595,326 @@ func TestSentinelGroup_MDo(t *testing.T) {
 		})
 	}
 }
+
+func TestSentinelGroup_Singleflight(t *testing.T) {
+	sg := NewSentinelGroup(WithSingleflight())
+
+	var count int
+	fDo := func(ctx context.Context, dest, args interface{}) error {
+		*(dest.(*int)) = count
+		count++
+		return nil
+	}
+
+	var resp1 int
+	err := sg.Do(context.TODO(), &resp1, "key", nil, fDo)
+	if assert.Nil(t, err) {
+		assert.Equal(t, 0, resp1)
+	}
+
+	// 不需要Delete，重新执行
+	var resp2 int
+	err = sg.Do(context.TODO(), &resp2, "key", nil, fDo)
+	if assert.Nil(t, err) {
+		assert.Equal(t, 1, resp2)
+	}
+}
+
+func TestSentinelGroup_DoPanic(t *testing.T) {
+	var sg SentinelGroup
+
+	var resp string
+	assert.PanicsWithValue(t, "wow", func() {
+		sg.Do(context.TODO(), &resp, "key", nil, func(ctx context.Context, destPtr, args interface{}) error {
+			panic("wow")
+		})
+	})
+
+	// 哨兵已经删除，重新执行
+	err := sg.Do(context.TODO(), &resp, "key", nil, func(ctx context.Context, destPtr, args interface{}) error {
+		*(destPtr.(*string)) = "ok"
+		return nil
+	})
+	if assert.Nil(t, err) {
+		assert.Equal(t, "ok", resp)
+	}
+}
+
+func TestSentinelGroup_MDoContractError(t *testing.T) {
+	var sg SentinelGroup
+
+	fDo := func(ctx context.Context, destSlicePtr, argsSlice interface{}) ([]error, error) {
+		destSlice := destSlicePtr.(*[]string)
+		for _, args := range argsSlice.([]string) {
+			*destSlice = append(*destSlice, "echo: "+args)
+		}
+		return nil, nil
+	}
+
+	// 参数不对
+	var resps []string
+	_, err := sg.MDo(context.TODO(), &resps, []string{"a", "b"}, []string{"A"}, fDo)
+	assert.EqualError(t, err, "wrong argsSlice")
+
+	// 哨兵已经删除，重新执行
+	resps = nil
+	errs, err := sg.MDo(context.TODO(), &resps, []string{"a", "b"}, []string{"A", "B"}, fDo)
+	if assert.Nil(t, err) && assert.Nil(t, errs) {
+		assert.Equal(t, []string{"echo: A", "echo: B"}, resps)
+	}
+
+	// 结果不够
+	started := make(chan struct{})
+	finish := make(chan struct{})
+	ch := make(chan error, 1)
+	go func() {
+		var resps []string
+		_, err := sg.MDo(context.TODO(), &resps, []string{"c"}, []string{"C"}, func(ctx context.Context, destSlicePtr, argsSlice interface{}) ([]error, error) {
+			close(started)
+			<-finish
+			return nil, nil
+		})
+		ch <- err
+	}()
+
+	// 等待其它调用者的执行，只是这个位置上的错误
+	<-started
+	time.AfterFunc(time.Millisecond*10, func() { close(finish) })
+	resps = nil
+	errs, err = sg.MDo(context.TODO(), &resps, []string{"c"}, []string{"C"}, fDo)
+	if assert.Nil(t, err) && assert.Len(t, errs, 1) {
+		assert.EqualError(t, errs[0], "not enough results")
+	}
+	assert.EqualError(t, <-ch, "not enough results")
+
+	// 哨兵已经删除，重新执行
+	resps = nil
+	errs, err = sg.MDo(context.TODO(), &resps, []string{"c"}, []string{"C"}, fDo)
+	if assert.Nil(t, err) && assert.Nil(t, errs) {
+		assert.Equal(t, []string{"echo: C"}, resps)
+	}
+}
+
+func TestSentinelGroup_DetachedContext(t *testing.T) {
+	sg := NewSentinelGroup(WithDetachedContext(time.Second))
+
+	var resp string
+	err := sg.Do(context.TODO(), &resp, "key", "hello", func(ctx context.Context, destPtr, args interface{}) error {
+		*(destPtr.(*string)) = "echo: " + args.(string)
+		return nil
+	})
+	if assert.Nil(t, err) {
+		assert.Equal(t, "echo: hello", resp)
+	}
+
+	var resps []string
+	errs, err := sg.MDo(context.TODO(), &resps, []string{"one", "two"}, []string{"one", "two"}, func(ctx context.Context, destSlicePtr, argsSlice interface{}) ([]error, error) {
+		for _, args := range argsSlice.([]string) {
+			*(destSlicePtr.(*[]string)) = append(*(destSlicePtr.(*[]string)), "echo: "+args)
+		}
+		return nil, nil
+	})
+	if assert.Nil(t, err) && assert.Nil(t, errs) {
+		assert.Equal(t, []string{"echo: one", "echo: two"}, resps)
+	}
+}
+
+func TestSentinelGroup_DoChan(t *testing.T) {
+	var sg SentinelGroup
+
+	var resp string
+	result := <-sg.DoChan(context.TODO(), &resp, "key", "hello", func(ctx context.Context, destPtr, args interface{}) error {
+		*(destPtr.(*string)) = "echo: " + args.(string)
+		return nil
+	})
+	if assert.Nil(t, result.Err) {
+		assert.Equal(t, "echo: hello", resp)
+		assert.False(t, result.Shared)
+	}
+
+	var resps []string
+	result = <-sg.MDoChan(context.TODO(), &resps, []string{"key", "other"}, []string{"key", "other"}, func(ctx context.Context, destSlicePtr, argsSlice interface{}) ([]error, error) {
+		for _, args := range argsSlice.([]string) {
+			*(destSlicePtr.(*[]string)) = append(*(destSlicePtr.(*[]string)), "echo: "+args)
+		}
+		return nil, nil
+	})
+	if assert.Nil(t, result.Err) && assert.Nil(t, result.Errs) {
+		assert.Equal(t, []string{"echo: hello", "echo: other"}, resps)
+		assert.True(t, result.Shared)
+	}
+
+	// Forget后重新执行
+	sg.Forget("key")
+	result = <-sg.DoChan(context.TODO(), &resp, "key", "world", func(ctx context.Context, destPtr, args interface{}) error {
+		*(destPtr.(*string)) = "echo: " + args.(string)
+		return nil
+	})
+	if assert.Nil(t, result.Err) {
+		assert.Equal(t, "echo: world", resp)
+	}
+}
+
+func TestSentinelGroupOf_StructKey(t *testing.T) {
+	type compositeKey struct {
+		Tenant string
+		ID     int
+	}
+	sg := NewSentinelGroupOf[compositeKey]()
+
+	var count int
+	fDo := func(ctx context.Context, destPtr, args interface{}) error {
+		key := args.(compositeKey)
+		*(destPtr.(*string)) = fmt.Sprintf("%s-%d-%d", key.Tenant, key.ID, count)
+		count++
+		return nil
+	}
+
+	key := compositeKey{Tenant: "a", ID: 1}
+	var resp string
+	err := sg.Do(context.TODO(), &resp, key, key, fDo)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "a-1-0", resp)
+	}
+
+	// 相等的key，直接取结果
+	err = sg.Do(context.TODO(), &resp, compositeKey{Tenant: "a", ID: 1}, key, fDo)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "a-1-0", resp)
+	}
+
+	// 批量
+	keys := []compositeKey{{Tenant: "a", ID: 1}, {Tenant: "b", ID: 1}}
+	var resps []string
+	errs, err := sg.MDo(context.TODO(), &resps, keys, keys, func(ctx context.Context, destSlicePtr, argsSlice interface{}) ([]error, error) {
+		for _, key := range argsSlice.([]compositeKey) {
+			*(destSlicePtr.(*[]string)) = append(*(destSlicePtr.(*[]string)), fmt.Sprintf("%s-%d-%d", key.Tenant, key.ID, count))
+			count++
+		}
+		return nil, nil
+	})
+	if assert.Nil(t, err) && assert.Nil(t, errs) {
+		assert.Equal(t, []string{"a-1-0", "b-1-1"}, resps)
+	}
+
+	// 删除后重新执行
+	sg.Delete(key)
+	err = sg.Do(context.TODO(), &resp, key, key, fDo)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "a-1-2", resp)
+	}
+}
+
+func TestSentinelGroup_MDoStream(t *testing.T) {
+	var sg SentinelGroup
+
+	var resps []string
+	errs, err := sg.MDoStream(context.TODO(), &resps, []string{"a", "b", "c"}, []string{"A", "B", "C"}, func(ctx context.Context, argsSlice interface{}, emit func(index int, value interface{}, err error)) error {
+		for index, args := range argsSlice.([]string) {
+			if args == "B" {
+				emit(index, nil, errors.New("test"))
+				continue
+			}
+			emit(index, "echo: "+args, nil)
+		}
+		return nil
+	})
+	if assert.Nil(t, err) && assert.Len(t, errs, 3) {
+		assert.Equal(t, []string{"echo: A", "echo: C"}, resps)
+		assert.EqualError(t, errs[1], "test")
+	}
+
+	// 参数不对
+	_, err = sg.MDoStream(context.TODO(), &resps, []string{"d"}, []string{}, func(ctx context.Context, argsSlice interface{}, emit func(index int, value interface{}, err error)) error {
+		return nil
+	})
+	assert.EqualError(t, err, "wrong argsSlice")
+}
+
+func TestSentinelGroup_MDoMap(t *testing.T) {
+	type Row struct {
+		ID   int
+		Name string
+	}
+	sg := NewSentinelGroupOf[int]()
+
+	var rows []Row
+	errs, err := sg.MDoMap(context.TODO(), &rows, []int{1, 2, 3}, []int{1, 2, 3}, func(ctx context.Context, destMapPtr interface{}, keys []int, argsSlice interface{}) (map[int]error, error) {
+		// SELECT id, name FROM table WHERE id IN (...)
+		destMap := *(destMapPtr.(*map[int]Row))
+		for _, id := range argsSlice.([]int) {
+			if id == 2 {
+				continue
+			}
+			destMap[id] = Row{ID: id, Name: fmt.Sprintf("name%d", id)}
+		}
+		return nil, nil
+	})
+	if assert.Nil(t, err) && assert.Len(t, errs, 3) {
+		assert.Equal(t, []Row{{ID: 1, Name: "name1"}, {ID: 3, Name: "name3"}}, rows)
+		assert.ErrorIs(t, errs[1], ErrNotFound)
+	}
+}
+
+func TestSentinelGroup_WaitOrDo(t *testing.T) {
+	sg := NewSentinelGroup(WithWaitOrDo(time.Millisecond * 20))
+
+	finish := make(chan struct{})
+	defer close(finish)
+	started := make(chan struct{})
+	var leaderResp string
+	go sg.Do(context.TODO(), &leaderResp, "key", nil, func(ctx context.Context, destPtr, args interface{}) error {
+		close(started)
+		<-finish
+		*(destPtr.(*string)) = "leader"
+		return nil
+	})
+	<-started
+
+	var resp string
+	err := sg.Do(context.TODO(), &resp, "key", nil, func(ctx context.Context, destPtr, args interface{}) error {
+		*(destPtr.(*string)) = "hedged"
+		return nil
+	})
+	if assert.Nil(t, err) {
+		assert.Equal(t, "hedged", resp)
+	}
+}
+
+func TestSentinelGroup_WaitOrDoProducerWins(t *testing.T) {
+	sg := NewSentinelGroup(WithWaitOrDo(time.Millisecond * 10))
+
+	finish := make(chan struct{})
+	started := make(chan struct{})
+	go sg.Do(context.TODO(), new(int), "key", nil, func(ctx context.Context, destPtr, args interface{}) error {
+		close(started)
+		<-finish
+		*(destPtr.(*int)) = 1
+		return nil
+	})
+	<-started
+
+	hedgeStarted := make(chan struct{})
+	hedgeRelease := make(chan struct{})
+	hedgeDone := make(chan struct{})
+	var resp int
+	ch := sg.DoChan(context.TODO(), &resp, "key", nil, func(ctx context.Context, destPtr, args interface{}) error {
+		defer close(hedgeDone)
+		close(hedgeStarted)
+		<-hedgeRelease
+		*(destPtr.(*int)) = 999
+		return nil
+	})
+
+	// 自己执行时，执行者的结果先到
+	<-hedgeStarted
+	close(finish)
+	result := <-ch
+	assert.Nil(t, result.Err)
+	assert.Equal(t, 1, resp)
+
+	// 自己执行的函数返回后，不修改调用者的结果
+	close(hedgeRelease)
+	<-hedgeDone
+	assert.Equal(t, 1, resp)
+}
@@ -0,0 +1,113 @@
+package sentinel
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// newToken 生成随机的锁token。
+func newToken() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b[:]), nil
+}
+
+type memoryLock struct {
+	token    string
+	deadline time.Time
+}
+
+type memoryResult struct {
+	data     []byte
+	deadline time.Time
+}
+
+// MemoryBackend 内存中的后端。用于测试，或者同一进程内的多个哨兵组共享。
+// 零值可用。
+type MemoryBackend struct {
+	mu      sync.Mutex
+	locks   map[string]memoryLock
+	results map[string]memoryResult
+}
+
+// NewMemoryBackend 新建内存中的后端。
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{}
+}
+
+// TryLock 尝试获得key的锁。
+func (b *MemoryBackend) TryLock(ctx context.Context, key string, ttl time.Duration) (token string, acquired bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	if lock, ok := b.locks[key]; ok && now.Before(lock.deadline) {
+		return "", false, nil
+	}
+
+	token, err = newToken()
+	if err != nil {
+		return "", false, err
+	}
+	if b.locks == nil {
+		b.locks = make(map[string]memoryLock)
+	}
+	b.locks[key] = memoryLock{token: token, deadline: now.Add(ttl)}
+	return token, true, nil
+}
+
+// Unlock 释放key的锁。
+func (b *MemoryBackend) Unlock(ctx context.Context, key string, token string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if lock, ok := b.locks[key]; ok && lock.token == token {
+		delete(b.locks, key)
+	}
+	return nil
+}
+
+// Load 读取key的结果。
+func (b *MemoryBackend) Load(ctx context.Context, key string) (data []byte, found bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	result, ok := b.results[key]
+	if !ok {
+		return nil, false, nil
+	}
+	if !time.Now().Before(result.deadline) {
+		delete(b.results, key)
+		return nil, false, nil
+	}
+	return append([]byte(nil), result.data...), true, nil
+}
+
+// Store 保存key的结果。
+func (b *MemoryBackend) Store(ctx context.Context, key string, data []byte, ttl time.Duration) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.results == nil {
+		b.results = make(map[string]memoryResult)
+	}
+	b.results[key] = memoryResult{
+		data:     append([]byte(nil), data...),
+		deadline: time.Now().Add(ttl),
+	}
+	return nil
+}
+
+// Delete 删除key的结果。
+func (b *MemoryBackend) Delete(ctx context.Context, key string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.results, key)
+	return nil
+}
@@ -0,0 +1,61 @@
+package sentinel
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Observer 观察哨兵组的执行和等待。用于统计请求合并的效果。
+// 方法在执行或者等待的协程中同步调用，不应阻塞。
+// 批量处理时，每个key分别调用。
+type Observer interface {
+	// OnProducerStart 开始执行key的逻辑。
+	OnProducerStart(key interface{})
+
+	// OnProducerFinish key的逻辑执行完成。
+	OnProducerFinish(key interface{}, duration time.Duration, err error)
+
+	// OnWaiterJoin 调用者等待其它过程执行key的结果。
+	OnWaiterJoin(key interface{})
+
+	// OnWaiterTimeout 调用者的ctx结束，放弃等待key的结果。
+	OnWaiterTimeout(key interface{}, err error)
+}
+
+// Stats 哨兵组的统计。按key计数。
+type Stats struct {
+	// Executions 执行次数。
+	Executions int64
+
+	// CoalescedWaits 等待其它过程的执行结果的次数。
+	CoalescedWaits int64
+
+	// Errors 执行失败的次数。
+	Errors int64
+
+	// InFlight 正在执行的key的个数。
+	InFlight int64
+
+	// Hedges 设置WithWaitOrDo时，等待者自己执行的次数。同时计入Executions。
+	Hedges int64
+}
+
+// groupStats 哨兵组的计数器。
+// 需要64位对齐，放在结构体的开头。
+type groupStats struct {
+	executions     int64
+	coalescedWaits int64
+	errors         int64
+	inFlight       int64
+	hedges         int64
+}
+
+func (s *groupStats) snapshot() Stats {
+	return Stats{
+		Executions:     atomic.LoadInt64(&s.executions),
+		CoalescedWaits: atomic.LoadInt64(&s.coalescedWaits),
+		Errors:         atomic.LoadInt64(&s.errors),
+		InFlight:       atomic.LoadInt64(&s.inFlight),
+		Hedges:         atomic.LoadInt64(&s.hedges),
+	}
+}
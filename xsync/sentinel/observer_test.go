@@ -0,0 +1,112 @@
+package sentinel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingObserver 记录事件的观察者。
+type recordingObserver struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (o *recordingObserver) record(event string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.events = append(o.events, event)
+}
+
+func (o *recordingObserver) OnProducerStart(key interface{}) {
+	o.record(fmt.Sprintf("start %v", key))
+}
+
+func (o *recordingObserver) OnProducerFinish(key interface{}, duration time.Duration, err error) {
+	o.record(fmt.Sprintf("finish %v %v", key, err))
+}
+
+func (o *recordingObserver) OnWaiterJoin(key interface{}) {
+	o.record(fmt.Sprintf("join %v", key))
+}
+
+func (o *recordingObserver) OnWaiterTimeout(key interface{}, err error) {
+	o.record(fmt.Sprintf("timeout %v %v", key, err))
+}
+
+func (o *recordingObserver) Events() []string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return append([]string(nil), o.events...)
+}
+
+func TestGroup_Observer(t *testing.T) {
+	observer := &recordingObserver{}
+	g := NewGroup[string, int](WithObserver(observer))
+
+	started := make(chan struct{})
+	finish := make(chan struct{})
+	ch := g.DoChan(context.TODO(), "key", func(ctx context.Context) (int, error) {
+		close(started)
+		<-finish
+		return 1, nil
+	})
+	<-started
+	assert.Equal(t, Stats{Executions: 1, InFlight: 1}, g.Stats())
+
+	// 等待超时
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Millisecond*10)
+	defer cancel()
+	_, err := g.Do(ctx, "key", nil)
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	close(finish)
+	result := <-ch
+	assert.Nil(t, result.Err)
+
+	// 已经有结果
+	value, err := g.Do(context.TODO(), "key", nil)
+	if assert.Nil(t, err) {
+		assert.Equal(t, 1, value)
+	}
+
+	// 失败
+	_, _, err = g.MDo(context.TODO(), []string{"key", "other"}, func(ctx context.Context, keys []string) ([]int, []error, error) {
+		return nil, []error{errors.New("test")}, nil
+	})
+	assert.Nil(t, err)
+
+	assert.Equal(t, Stats{Executions: 2, CoalescedWaits: 3, Errors: 1}, g.Stats())
+	assert.Equal(t, []string{
+		"start key",
+		"join key",
+		"timeout key context deadline exceeded",
+		"finish key <nil>",
+		"join key",
+		"join key",
+		"start other",
+		"finish other test",
+	}, observer.Events())
+}
+
+func TestSentinelGroup_Stats(t *testing.T) {
+	var sg SentinelGroup
+
+	fDo := func(ctx context.Context, destPtr, args interface{}) error {
+		*(destPtr.(*string)) = "ok"
+		return nil
+	}
+	var resp string
+	for i := 0; i < 3; i++ {
+		err := sg.Do(context.TODO(), &resp, "key", nil, fDo)
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, Stats{Executions: 1, CoalescedWaits: 2}, sg.Stats())
+}
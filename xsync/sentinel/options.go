@@ -0,0 +1,103 @@
+package sentinel
+
+import "time"
+
+// Option 哨兵组的选项。
+type Option func(*options)
+
+type options struct {
+	// release 执行完成后，是否删除key。
+	release bool
+
+	// retention 执行完成后，删除key前保留结果的时长。
+	retention time.Duration
+
+	// detached 是否独立执行。
+	detached bool
+
+	// maxExecution 独立执行时，最长的执行时间。
+	maxExecution time.Duration
+
+	// observer 观察者。可以为nil。
+	observer Observer
+
+	// cloner 复制结果。为nil时不复制。
+	cloner func(value interface{}) (interface{}, error)
+
+	// typedCloner WithCloner的参数，func(V) V。Group的V相同时，直接使用，不需要类型断言。
+	typedCloner interface{}
+
+	// retry 重试策略。为nil时不重试。
+	retry *RetryPolicy
+
+	// distributed 跨进程协调。为nil时只在进程内合并请求。
+	distributed *distributor
+
+	// waitTimeout 等待者最长的等待时间。
+	waitTimeout time.Duration
+
+	// hedgeDelay 等待者等待多久后，自己执行。
+	hedgeDelay time.Duration
+}
+
+func newOptions(opts ...Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithSingleflight 执行完成后立即删除key，只合并同时进行的请求。
+// 默认在调用Delete前一直保留结果。
+func WithSingleflight() Option {
+	return func(o *options) {
+		o.release = true
+		o.retention = 0
+	}
+}
+
+// WithRetention 执行完成后保留结果d时长，再删除key，作为短期的缓存。
+// d<=0时，同WithSingleflight。
+func WithRetention(d time.Duration) Option {
+	return func(o *options) {
+		o.release = true
+		o.retention = d
+	}
+}
+
+// WithDetachedContext 独立执行模式。
+// 执行的函数在新协程中执行，使用的context保留第一个调用者的ctx的Value，但不随它取消。
+// 只有全部调用者都放弃等待时，才取消执行。
+// maxExecution>0时，限制执行的最长时间。
+func WithDetachedContext(maxExecution time.Duration) Option {
+	return func(o *options) {
+		o.detached = true
+		o.maxExecution = maxExecution
+	}
+}
+
+// WithObserver 设置观察者。
+func WithObserver(observer Observer) Option {
+	return func(o *options) {
+		o.observer = observer
+	}
+}
+
+// WithWaitTimeout 等待者最长等待d时长。超时时，等待者得到context.DeadlineExceeded。
+// ctx的截止时间更早时，以ctx为准。
+func WithWaitTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.waitTimeout = d
+	}
+}
+
+// WithWaitOrDo Do的等待者等待delay时长后还没有结果，自己执行f，使用最先得到的结果。
+// 等待者自己执行的结果不保存，也不通知其它等待者。
+// 设置WithDistributed时，等待者自己执行不通过后端协调，直接执行f，也不保存结果到后端。
+// 用于降低执行缓慢时的长尾延迟。批量处理时不生效。
+func WithWaitOrDo(delay time.Duration) Option {
+	return func(o *options) {
+		o.hedgeDelay = delay
+	}
+}
@@ -0,0 +1,159 @@
+package sentinel
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy 重试策略。由执行的调用者代替全部等待者重试。
+type RetryPolicy struct {
+	// MaxAttempts 最多执行的次数，包括第一次。小于等于1时不重试。
+	MaxAttempts int
+
+	// InitialBackoff 第一次重试前等待的时长。默认100毫秒。
+	InitialBackoff time.Duration
+
+	// MaxBackoff 重试前等待的最长时长。为0时不限制。
+	MaxBackoff time.Duration
+
+	// Multiplier 每次重试后，等待时长乘以的倍数。默认2。
+	Multiplier float64
+
+	// Jitter 随机减少等待时长的比例，取值[0, 1]。避免多个进程同时重试。
+	Jitter float64
+
+	// Retryable 错误是否可以重试。为nil时，全部错误都可以重试。
+	// context的错误、*NotFoundError、结果不合格的错误不重试。
+	Retryable func(err error) bool
+}
+
+// WithRetry 执行失败时，按policy重试。
+// 重试遵守ctx的截止时间：等待后会超过截止时间时，不再重试。
+// 批量处理时，只重试失败的key。MDoStream不重试。
+func WithRetry(policy RetryPolicy) Option {
+	if policy.InitialBackoff <= 0 {
+		policy.InitialBackoff = time.Millisecond * 100
+	}
+	if policy.Multiplier <= 0 {
+		policy.Multiplier = 2
+	}
+	return func(o *options) {
+		o.retry = &policy
+	}
+}
+
+// retryable 错误是否可以重试。
+func (p *RetryPolicy) retryable(err error) bool {
+	var fatalErr *fatalError
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return false
+	case errors.Is(err, ErrNotFound), errors.Is(err, errNotEnoughResults), errors.As(err, &fatalErr):
+		return false
+	case p.Retryable != nil:
+		return p.Retryable(err)
+	}
+	return true
+}
+
+// backoff 第attempt次执行失败后，重试前等待的时长。
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	d := float64(p.InitialBackoff)
+	for i := 1; i < attempt; i++ {
+		d *= p.Multiplier
+		if p.MaxBackoff > 0 && d >= float64(p.MaxBackoff) {
+			break
+		}
+	}
+	if p.MaxBackoff > 0 && d > float64(p.MaxBackoff) {
+		d = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		d -= d * p.Jitter * rand.Float64()
+	}
+	return time.Duration(d)
+}
+
+// sleep 等待d时长。如果ctx结束，或者等待后会超过ctx的截止时间，返回false。
+func (p *RetryPolicy) sleep(ctx context.Context, d time.Duration) bool {
+	if deadline, ok := ctx.Deadline(); ok && time.Now().Add(d).After(deadline) {
+		return false
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// retryDo 按策略重试f。p为nil时，直接返回f。
+func retryDo[V any](p *RetryPolicy, f GroupDoFunc[V]) GroupDoFunc[V] {
+	if p == nil {
+		return f
+	}
+	return func(ctx context.Context) (result V, err error) {
+		for attempt := 1; ; attempt++ {
+			result, err = f(ctx)
+			if err == nil || attempt >= p.MaxAttempts || !p.retryable(err) {
+				return result, err
+			}
+			if !p.sleep(ctx, p.backoff(attempt)) {
+				return result, err
+			}
+		}
+	}
+}
+
+// retryMDo 按策略重试f，只重试失败的key。p为nil时，直接返回f。
+func retryMDo[K comparable, V any](p *RetryPolicy, f GroupMDoFunc[K, V]) GroupMDoFunc[K, V] {
+	if p == nil {
+		return f
+	}
+	return func(ctx context.Context, keys []K) ([]V, []error, error) {
+		values := make([]V, len(keys))
+		errs := make([]error, len(keys))
+
+		pending := make([]int, len(keys)) // 需要执行的key的下标
+		for i := range keys {
+			pending[i] = i
+		}
+		for attempt := 1; ; attempt++ {
+			pendingKeys := make([]K, len(pending))
+			for i, index := range pending {
+				pendingKeys[i] = keys[index]
+			}
+
+			pendingValues, pendingErrs, err := f(ctx, pendingKeys)
+			if err == nil && !enoughResults(pendingValues, pendingErrs, len(pendingKeys)) {
+				return nil, nil, errNotEnoughResults
+			}
+
+			var retries []int
+			for i, index := range pending {
+				elemErr := err
+				if elemErr == nil && len(pendingErrs) > i { // 允许省去后面的nil
+					elemErr = pendingErrs[i]
+				}
+				if elemErr == nil {
+					values[index], errs[index] = pendingValues[i], nil
+					continue
+				}
+				errs[index] = elemErr
+				if p.retryable(elemErr) {
+					retries = append(retries, index)
+				}
+			}
+
+			if len(retries) == 0 || attempt >= p.MaxAttempts || !p.sleep(ctx, p.backoff(attempt)) {
+				return values, errs, nil
+			}
+			pending = retries
+		}
+	}
+}
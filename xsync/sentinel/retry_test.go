@@ -0,0 +1,177 @@
+package sentinel
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var errTransient = errors.New("transient")
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	p := RetryPolicy{InitialBackoff: time.Millisecond * 10, MaxBackoff: time.Millisecond * 50, Multiplier: 2}
+	assert.Equal(t, time.Millisecond*10, p.backoff(1))
+	assert.Equal(t, time.Millisecond*20, p.backoff(2))
+	assert.Equal(t, time.Millisecond*40, p.backoff(3))
+	assert.Equal(t, time.Millisecond*50, p.backoff(4))
+	assert.Equal(t, time.Millisecond*50, p.backoff(100))
+
+	p.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		d := p.backoff(1)
+		assert.GreaterOrEqual(t, d, time.Millisecond*5)
+		assert.LessOrEqual(t, d, time.Millisecond*10)
+	}
+}
+
+func TestGroup_RetryDo(t *testing.T) {
+	g := NewGroup[string, int](WithRetry(RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond * 10,
+	}))
+
+	// 第三次成功
+	var attempts int32
+	fDo := func(ctx context.Context) (int, error) {
+		n := atomic.AddInt32(&attempts, 1)
+		if n < 3 {
+			return 0, errTransient
+		}
+		return int(n), nil
+	}
+
+	// 等待者也得到重试后的结果
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			result, err := g.Do(context.TODO(), "key", fDo)
+			if assert.Nil(t, err) {
+				assert.Equal(t, 3, result)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(3), attempts)
+
+	// 超过最多次数
+	attempts = 0
+	_, err := g.Do(context.TODO(), "always", func(ctx context.Context) (int, error) {
+		atomic.AddInt32(&attempts, 1)
+		return 0, errTransient
+	})
+	assert.Equal(t, errTransient, err)
+	assert.Equal(t, int32(3), attempts)
+}
+
+func TestGroup_RetryRetryable(t *testing.T) {
+	g := NewGroup[string, int](WithRetry(RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		Retryable: func(err error) bool {
+			return err == errTransient
+		},
+	}))
+
+	var attempts int32
+	_, err := g.Do(context.TODO(), "key", func(ctx context.Context) (int, error) {
+		atomic.AddInt32(&attempts, 1)
+		return 0, errors.New("permanent")
+	})
+	assert.EqualError(t, err, "permanent")
+	assert.Equal(t, int32(1), attempts)
+}
+
+func TestGroup_RetryDeadline(t *testing.T) {
+	g := NewGroup[string, int](WithRetry(RetryPolicy{
+		MaxAttempts:    10,
+		InitialBackoff: time.Millisecond * 100,
+	}))
+
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Millisecond*50)
+	defer cancel()
+
+	var attempts int32
+	start := time.Now()
+	_, err := g.Do(ctx, "key", func(ctx context.Context) (int, error) {
+		atomic.AddInt32(&attempts, 1)
+		return 0, errTransient
+	})
+	assert.Equal(t, errTransient, err)
+	// 等待后会超过截止时间，不再重试
+	assert.Equal(t, int32(1), attempts)
+	assert.Less(t, time.Since(start), time.Millisecond*50)
+}
+
+func TestGroup_RetryMDo(t *testing.T) {
+	g := NewGroup[int, int](WithRetry(RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+	}))
+
+	var calls [][]int
+	results, errs, err := g.MDo(context.TODO(), []int{1, 2, 3}, func(ctx context.Context, keys []int) ([]int, []error, error) {
+		calls = append(calls, append([]int(nil), keys...))
+
+		results := make([]int, len(keys))
+		errs := make([]error, len(keys))
+		for i, key := range keys {
+			switch {
+			case key == 2 && len(calls) < 3:
+				errs[i] = errTransient
+			case key == 3:
+				errs[i] = &NotFoundError{Key: key} // 不重试
+			default:
+				results[i] = key * 10
+			}
+		}
+		return results, errs, nil
+	})
+	if assert.Nil(t, err) && assert.Len(t, errs, 3) {
+		assert.Equal(t, []int{10, 20, 0}, results)
+		assert.Nil(t, errs[0])
+		assert.Nil(t, errs[1])
+		assert.ErrorIs(t, errs[2], ErrNotFound)
+	}
+	// 只重试失败的key
+	assert.Equal(t, [][]int{{1, 2, 3}, {2}, {2}}, calls)
+}
+
+func TestSentinelGroup_Retry(t *testing.T) {
+	sg := NewSentinelGroup(WithRetry(RetryPolicy{
+		MaxAttempts:    2,
+		InitialBackoff: time.Millisecond,
+	}))
+
+	var attempts int
+	var resp string
+	err := sg.Do(context.TODO(), &resp, "key", nil, func(ctx context.Context, destPtr, args interface{}) error {
+		attempts++
+		if attempts == 1 {
+			return errTransient
+		}
+		*(destPtr.(*string)) = "ok"
+		return nil
+	})
+	if assert.Nil(t, err) {
+		assert.Equal(t, "ok", resp)
+	}
+	assert.Equal(t, 2, attempts)
+
+	// 参数不对，不重试
+	attempts = 0
+	var resps []string
+	_, err = sg.MDo(context.TODO(), &resps, []string{"a"}, []string{}, func(ctx context.Context, destSlicePtr, argsSlice interface{}) ([]error, error) {
+		attempts++
+		return nil, nil
+	})
+	assert.EqualError(t, err, "wrong argsSlice")
+	assert.Equal(t, 0, attempts)
+}
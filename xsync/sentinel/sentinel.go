@@ -2,75 +2,166 @@ package sentinel
 
 import (
 	"context"
-	"errors"
 	"reflect"
+	"sync"
 )
 
 // Sentinel 哨兵。一个生产者，多个消费者等待生产者完成并提交结果。
+// 并发安全。
 // 从https://github.com/wencan/cachex/blob/master/sentinel.go修改来。
 type Sentinel struct {
-	flag   chan interface{}
+	typed *TypedSentinel[interface{}]
+}
+
+// NewSentinel 新建哨兵
+func NewSentinel() *Sentinel {
+	return &Sentinel{
+		typed: NewTypedSentinel[interface{}](),
+	}
+}
+
+// Done 生产者提交结果。
+// Wait的resultPtr是指向Done的result的指针。
+// 如果已经Done或者Close，不做修改，返回ErrAlreadyDone。
+func (s *Sentinel) Done(result interface{}, err error) error {
+	return s.typed.Done(result, err)
+}
+
+// Wait 消费者等待生产者提交结果。
+// Wait的resultPtr是指向Done的result的指针
+// resultPtr指向的内容是共享的，不可修改。
+func (s *Sentinel) Wait(ctx context.Context, resultPtr interface{}) error {
+	checkResultPtr(resultPtr)
+
+	result, err := s.typed.Wait(ctx)
+	if err != nil {
+		return err
+	}
+	setResult(resultPtr, result)
+	return nil
+}
+
+// TryWait 同Wait，但不阻塞。如果生产者还没提交结果，返回ErrNotReady。
+func (s *Sentinel) TryWait(resultPtr interface{}) error {
+	checkResultPtr(resultPtr)
+
+	result, err := s.typed.TryWait()
+	if err != nil {
+		return err
+	}
+	setResult(resultPtr, result)
+	return nil
+}
+
+// Close 关闭。
+// 如果还没有Done，等待者得到ErrAbandoned。
+func (s *Sentinel) Close() {
+	s.typed.Close()
+}
+
+func checkResultPtr(resultPtr interface{}) {
+	ptr := reflect.ValueOf(resultPtr)
+	if ptr.Kind() != reflect.Ptr || ptr.IsNil() {
+		panic("value must is a non-nil pointer")
+	}
+}
+
+func setResult(resultPtr interface{}, result interface{}) {
+	if result != nil {
+		value := reflect.ValueOf(result)
+		reflect.ValueOf(resultPtr).Elem().Set(value)
+	}
+}
+
+// TypedSentinel 泛型的哨兵。同Sentinel，但不使用反射。
+// 并发安全。
+type TypedSentinel[V any] struct {
+	mu     sync.Mutex
+	flag   chan struct{}
 	closed bool
 
 	// done 是否已经执行Done()
 	done bool
 
-	result interface{}
+	result V
 	err    error
 }
 
-// NewSentinel 新建哨兵
-func NewSentinel() *Sentinel {
-	return &Sentinel{
-		flag: make(chan interface{}),
+// NewTypedSentinel 新建泛型的哨兵。
+func NewTypedSentinel[V any]() *TypedSentinel[V] {
+	return &TypedSentinel[V]{
+		flag: make(chan struct{}),
 	}
 }
 
 // Done 生产者提交结果。
-// Wait的resultPtr是指向Done的result的指针。
-func (s *Sentinel) Done(result interface{}, err error) {
+// 如果已经Done或者Close，不做修改，返回ErrAlreadyDone。
+func (s *TypedSentinel[V]) Done(result V, err error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrAlreadyDone
+	}
+
 	s.done = true
 	s.result = result
 	s.err = err
 
 	close(s.flag)
 	s.closed = true
+	return nil
 }
 
 // Wait 消费者等待生产者提交结果。
-// Wait的resultPtr是指向Done的result的指针
-// resultPtr指向的内容是共享的，不可修改。
-func (s *Sentinel) Wait(ctx context.Context, resultPtr interface{}) error {
-	ptr := reflect.ValueOf(resultPtr)
-	if ptr.Kind() != reflect.Ptr || ptr.IsNil() {
-		panic("value must is a non-nil pointer")
+// 返回的结果是共享的，不可修改。
+func (s *TypedSentinel[V]) Wait(ctx context.Context) (result V, err error) {
+	select {
+	case <-s.flag:
+	case <-ctx.Done():
+		return result, ctx.Err()
 	}
+	return s.get()
+}
 
+// TryWait 同Wait，但不阻塞。如果生产者还没提交结果，返回ErrNotReady。
+func (s *TypedSentinel[V]) TryWait() (result V, err error) {
 	select {
 	case <-s.flag:
-	case <-ctx.Done():
-		return ctx.Err()
+	default:
+		return result, ErrNotReady
 	}
+	return s.get()
+}
 
+// get 取提交的结果。flag关闭后才能调用。
+// flag关闭后，结果不再修改，不需要加锁。
+func (s *TypedSentinel[V]) get() (result V, err error) {
 	if !s.done {
 		// 没done，却返回了，说明还没done，s.flag就被close了。
-		return errors.New("internal error")
+		return result, ErrAbandoned
 	}
 
 	if s.err != nil {
-		return s.err
+		return result, s.err
 	}
+	return s.result, nil
+}
 
-	if s.result != nil {
-		value := reflect.ValueOf(s.result)
-		reflect.ValueOf(resultPtr).Elem().Set(value)
-	}
+// isDone 是否已经执行Done()。
+func (s *TypedSentinel[V]) isDone() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	return nil
+	return s.done
 }
 
 // Close 关闭。
-func (s *Sentinel) Close() {
+// 如果还没有Done，等待者得到ErrAbandoned。
+func (s *TypedSentinel[V]) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	if !s.closed {
 		close(s.flag)
 		s.closed = true
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"reflect"
 	"sync"
+	"sync/atomic"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -81,3 +82,124 @@ func TestSentinel_Wait(t *testing.T) {
 		})
 	}
 }
+
+func TestTypedSentinel_Wait(t *testing.T) {
+	s := NewTypedSentinel[[]int]()
+	defer s.Close()
+
+	var wg sync.WaitGroup
+	wg.Add(500)
+	for i := 0; i < 500; i++ {
+		go func() {
+			defer wg.Done()
+
+			result, err := s.Wait(context.TODO())
+			if assert.Nil(t, err) {
+				assert.Equal(t, []int{1, 2, 3}, result)
+			}
+		}()
+	}
+
+	s.Done([]int{1, 2, 3}, nil)
+
+	wg.Wait()
+
+	// 出错
+	s2 := NewTypedSentinel[int]()
+	s2.Done(1, errors.New("test"))
+	_, err := s2.Wait(context.TODO())
+	assert.NotNil(t, err)
+
+	// 超时
+	s3 := NewTypedSentinel[int]()
+	defer s3.Close()
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	_, err = s3.Wait(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestSentinel_Abandoned(t *testing.T) {
+	s := NewSentinel()
+	s.Close()
+
+	var result int
+	err := s.Wait(context.TODO(), &result)
+	assert.ErrorIs(t, err, ErrAbandoned)
+
+	ts := NewTypedSentinel[int]()
+	ts.Close()
+	_, err = ts.Wait(context.TODO())
+	assert.ErrorIs(t, err, ErrAbandoned)
+}
+
+func TestSentinel_DoneTwice(t *testing.T) {
+	s := NewSentinel()
+	assert.Nil(t, s.Done(1, nil))
+	assert.ErrorIs(t, s.Done(2, nil), ErrAlreadyDone)
+	s.Close() // 不panic
+
+	var result int
+	err := s.Wait(context.TODO(), &result)
+	if assert.Nil(t, err) {
+		assert.Equal(t, 1, result)
+	}
+
+	// Close后Done
+	ts := NewTypedSentinel[int]()
+	ts.Close()
+	assert.ErrorIs(t, ts.Done(1, nil), ErrAlreadyDone)
+	_, err = ts.Wait(context.TODO())
+	assert.ErrorIs(t, err, ErrAbandoned)
+}
+
+func TestSentinel_ConcurrentlyDone(t *testing.T) {
+	s := NewTypedSentinel[int]()
+
+	var wg sync.WaitGroup
+	var succeeded int32
+	for i := 0; i < 100; i++ {
+		wg.Add(3)
+		go func(i int) {
+			defer wg.Done()
+
+			if s.Done(i, nil) == nil {
+				atomic.AddInt32(&succeeded, 1)
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+
+			s.Close()
+		}()
+		go func() {
+			defer wg.Done()
+
+			s.Wait(context.TODO())
+		}()
+	}
+	wg.Wait()
+
+	// 最多只有一次Done成功
+	assert.LessOrEqual(t, atomic.LoadInt32(&succeeded), int32(1))
+}
+
+func TestSentinel_TryWait(t *testing.T) {
+	s := NewSentinel()
+
+	var result string
+	assert.ErrorIs(t, s.TryWait(&result), ErrNotReady)
+
+	s.Done("ok", nil)
+	err := s.TryWait(&result)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "ok", result)
+	}
+
+	ts := NewTypedSentinel[int]()
+	_, err = ts.TryWait()
+	assert.ErrorIs(t, err, ErrNotReady)
+	ts.Done(0, errors.New("test"))
+	_, err = ts.TryWait()
+	assert.EqualError(t, err, "test")
+}